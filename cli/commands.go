@@ -2,11 +2,16 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	"mariadb-monitor/core"
 	"golang.org/x/term"
@@ -17,6 +22,11 @@ type CLI struct{}
 
 // NewCLI creates a new CLI instance
 func NewCLI() *CLI {
+	// Initializing a new data directory can take a while, so show its progress.
+	// It goes to stderr, which stays out of output meant for eval.
+	core.OnInitProgress(func(configName, line string) {
+		fmt.Fprintf(os.Stderr, "  [init] %s\n", line)
+	})
 	return &CLI{}
 }
 
@@ -44,11 +54,30 @@ func (c *CLI) List() error {
 		
 		fmt.Printf("\n   Port: %s", config.Port)
 		
+		if core.IsContainerConfig(config) {
+			fmt.Printf("\n   Runtime: container (%s)", core.ContainerImage(config))
+		} else {
+			binDir := core.BinDirForConfig(config)
+			fmt.Printf("\n   Server: %s %s (%s)", core.GetBinaryFlavor(binDir).Name, core.ConfigServerVersion(config), binDir)
+		}
+		
 		if config.DataDir != "" {
 			fmt.Printf("\n   Data: %s", config.DataDir)
+			if server, ok := core.DetectDataDirServer(core.ResolveDataDir(config)); ok {
+				fmt.Printf(" (%s)", server)
+			}
+			if upgrade := core.CheckUpgradeNeeded(config); upgrade.Needed {
+				fmt.Printf("\n   Upgrade: needed from %s - run 'dbswitcher upgrade %s'", upgrade.DataVersion, config.Name)
+			}
 		}
 		
 		fmt.Printf("\n   File: %s", config.Path)
+		if core.IsProtectedConfig(config) {
+			fmt.Printf(" (protected)")
+		}
+		if core.IsOnDemandConfig(config) {
+			fmt.Printf(" (on-demand, idle stop after %v)", core.OnDemandIdleTimeout(config))
+		}
 		
 		// Mark active configuration (normalize paths for comparison)
 		if status.IsRunning && filepath.Clean(config.Path) == filepath.Clean(status.ConfigFile) {
@@ -88,9 +117,10 @@ func (c *CLI) Status() error {
 	return nil
 }
 
-// Switch switches to a different configuration
-func (c *CLI) Switch(configName string) error {
-	fmt.Printf("Switching to configuration: %s\n", configName)
+// Switch switches to a different configuration, writing progress and
+// prompts to out
+func (c *CLI) Switch(configName string, out io.Writer) error {
+	fmt.Fprintf(out, "Switching to configuration: %s\n", configName)
 	
 	// Find the configuration
 	var targetConfig *core.MariaDBConfig
@@ -105,32 +135,19 @@ func (c *CLI) Switch(configName string) error {
 		return fmt.Errorf("configuration '%s' not found", configName)
 	}
 	
-	// Check if MariaDB is currently running
-	if core.IsMariaDBRunning() {
-		fmt.Println("MariaDB is currently running. Stopping it first...")
-		
-		if err := c.Stop(); err != nil {
-			return fmt.Errorf("failed to stop current MariaDB instance: %v", err)
-		}
-		
-		fmt.Println("Waiting for shutdown to complete...")
-		// Brief wait to ensure complete shutdown
-		core.AppLogger.Log("Waiting for complete shutdown before switching...")
-		// Add a simple wait here
+	stop := func() error {
+		fmt.Fprintln(out, "MariaDB is currently running. Stopping it first...")
+		return c.Stop(out)
 	}
 	
-	// Start with new configuration
-	fmt.Printf("Starting MariaDB with %s configuration...\n", targetConfig.Name)
-	
-	err := core.StartMariaDBWithConfig(targetConfig.Path)
-	if err != nil {
-		return fmt.Errorf("failed to start MariaDB: %v", err)
+	if err := core.SwitchToConfig(*targetConfig, stop); err != nil {
+		return err
 	}
 	
-	fmt.Printf("✓ Successfully switched to %s configuration\n", targetConfig.Name)
-	fmt.Printf("  Port: %s\n", targetConfig.Port)
+	fmt.Fprintf(out, "✓ Successfully switched to %s configuration\n", targetConfig.Name)
+	fmt.Fprintf(out, "  Port: %s\n", targetConfig.Port)
 	if targetConfig.DataDir != "" {
-		fmt.Printf("  Data Directory: %s\n", targetConfig.DataDir)
+		fmt.Fprintf(out, "  Data Directory: %s\n", targetConfig.DataDir)
 	}
 	
 	return nil
@@ -165,6 +182,7 @@ func (c *CLI) Start(configName string) error {
 	
 	err := core.StartMariaDBWithConfig(targetConfig.Path)
 	if err != nil {
+		core.NotifySwitchFailed(targetConfig.Name, err)
 		return fmt.Errorf("failed to start MariaDB: %v", err)
 	}
 	
@@ -175,38 +193,726 @@ func (c *CLI) Start(configName string) error {
 	return nil
 }
 
-// Stop stops the running MariaDB instance
-func (c *CLI) Stop() error {
+// Stop stops the running MariaDB instance, writing progress and prompts to
+// out
+func (c *CLI) Stop(out io.Writer) error {
 	if !core.IsMariaDBRunning() {
-		fmt.Println("MariaDB is not currently running.")
+		fmt.Fprintln(out, "MariaDB is not currently running.")
 		return nil
 	}
 	
-	fmt.Println("Stopping MariaDB...")
+	fmt.Fprintln(out, "Stopping MariaDB...")
 	
-	// Try to get credentials for graceful shutdown
-	creds, err := c.promptForCredentials()
-	if err != nil {
-		return fmt.Errorf("failed to get credentials: %v", err)
+	// Containers are stopped through the container CLI and need no credentials
+	var creds core.MySQLCredentials
+	status := core.GetMariaDBStatus()
+	cfg := core.FindConfigByPath(status.ConfigFile)
+	if hasOwnCredentials(cfg) {
+		// Initialization generated a root password for this configuration
+		creds = core.GetCredentialsForConfig(*cfg)
+	} else if cfg == nil || !core.IsContainerConfig(*cfg) {
+		var err error
+		if creds, err = c.promptForCredentials(out); err != nil {
+			return fmt.Errorf("failed to get credentials: %v", err)
+		}
 	}
 	
 	// Attempt graceful shutdown
-	err = core.StopMySQLWithCredentials(creds)
+	err := core.StopMySQLWithCredentials(creds)
 	if err != nil {
 		return fmt.Errorf("failed to stop MariaDB gracefully: %v", err)
 	}
 	
-	fmt.Println("✓ MariaDB stopped successfully")
+	fmt.Fprintln(out, "✓ MariaDB stopped successfully")
 	return nil
 }
 
-// promptForCredentials prompts the user for MySQL credentials
-func (c *CLI) promptForCredentials() (core.MySQLCredentials, error) {
+// DiskUsage shows data directory and schema sizes for a configuration
+func (c *CLI) DiskUsage(configName string) error {
+	targetConfig := core.FindConfigByName(configName)
+	if targetConfig == nil {
+		return fmt.Errorf("configuration '%s' not found", configName)
+	}
+
+	status := core.GetMariaDBStatus()
+	running := core.IsConfigActive(*targetConfig, status)
+
+	usage, err := core.GetDataDirUsage(*targetConfig, running)
+	if err != nil {
+		return fmt.Errorf("failed to compute disk usage: %v", err)
+	}
+
+	title := fmt.Sprintf("Disk Usage: %s", targetConfig.Name)
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", len(title)))
+
+	fmt.Printf("Data Directory: %s\n", usage.DataDir)
+	fmt.Printf("Total Size: %s\n", core.FormatBytes(usage.TotalBytes))
+	if usage.FreeBytes >= 0 {
+		fmt.Printf("Free Space: %s\n", core.FormatBytes(usage.FreeBytes))
+	} else {
+		fmt.Printf("Free Space: unknown\n")
+	}
+	fmt.Printf("InnoDB Logs: %s\n", core.FormatBytes(usage.InnoDBLogBytes))
+	fmt.Printf("InnoDB Undo: %s\n", core.FormatBytes(usage.UndoBytes))
+	fmt.Printf("Last Modified: %s\n", usage.LastModified.Format("2006-01-02 15:04:05"))
+
+	fmt.Printf("\nSchemas (from %s):\n", usage.SchemaSource)
+	if len(usage.Schemas) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, schema := range usage.Schemas {
+		fmt.Printf("  %-30s %10s\n", schema.Name, core.FormatBytes(schema.SizeBytes))
+	}
+
+	return nil
+}
+
+// Service manages the systemd service of a configuration
+func (c *CLI) Service(action, configName string) error {
+	if !core.IsSystemdAvailable() {
+		return fmt.Errorf("systemd is not available on this system")
+	}
+
+	targetConfig := core.FindConfigByName(configName)
+	if targetConfig == nil {
+		return fmt.Errorf("configuration '%s' not found", configName)
+	}
+	unit := core.ServiceUnitName(*targetConfig)
+
+	switch action {
+	case "install":
+		mysqldPath, err := core.ResolveMysqldPath(core.BinDirForConfig(*targetConfig))
+		if err != nil {
+			return err
+		}
+		if err := core.InstallService(*targetConfig, mysqldPath); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Installed %s\n", unit)
+
+	case "uninstall":
+		if err := core.UninstallService(*targetConfig); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Removed %s\n", unit)
+
+	case "enable", "disable":
+		if err := core.EnableService(*targetConfig, action == "enable"); err != nil {
+			return err
+		}
+		fmt.Printf("✓ %s %sd\n", unit, action)
+
+	case "status":
+		state, err := core.GetConfigServiceState(*targetConfig)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Unit: %s (%s)\n", state.Unit, state.LoadState)
+		fmt.Printf("State: %s (%s)\n", state.ActiveState, state.SubState)
+		if state.UnitFileState != "" {
+			fmt.Printf("Enabled: %s\n", state.UnitFileState)
+		}
+		if state.MainPID > 0 {
+			fmt.Printf("PID: %d\n", state.MainPID)
+		}
+
+	default:
+		return fmt.Errorf("unknown service action '%s' (use install, uninstall, enable, disable or status)", action)
+	}
+
+	return nil
+}
+
+// Installations lists, registers, removes and pins server installations
+func (c *CLI) Installations(args []string) error {
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "list":
+		if len(core.AppConfig.Installations) == 0 {
+			fmt.Println("No installations registered.")
+			fmt.Printf("Configurations use the global MariaDB binary directory: %s\n", core.AppConfig.MariaDBBin)
+			return nil
+		}
+		fmt.Println("Registered Installations:")
+		fmt.Println("========================")
+		for _, inst := range core.AppConfig.Installations {
+			fmt.Printf("%-10s %-8s %-24s %s\n", inst.Name, inst.Flavor, inst.Version, inst.BinDir)
+		}
+		pinned := make([]string, 0, len(core.AppConfig.ConfigInstallations))
+		for configName := range core.AppConfig.ConfigInstallations {
+			pinned = append(pinned, configName)
+		}
+		sort.Strings(pinned)
+		for _, configName := range pinned {
+			fmt.Printf("  %s is pinned to %s\n", configName, core.AppConfig.ConfigInstallations[configName])
+		}
+
+	case "discover":
+		fmt.Println("Discovered Installations:")
+		fmt.Println("========================")
+		found := core.DiscoverInstallations()
+		if len(found) == 0 {
+			fmt.Println("No MariaDB, MySQL or Percona installations found.")
+		}
+		for _, inst := range found {
+			fmt.Println(inst.Label())
+			if len(inst.Tools) > 0 {
+				fmt.Printf("   Tools: %s\n", strings.Join(inst.Tools, ", "))
+			}
+		}
+		fmt.Println("\nRegister one with: dbswitcher installations add <bin-dir> [name]")
+
+	case "add":
+		if len(args) < 2 {
+			return fmt.Errorf("bin directory required")
+		}
+		name := ""
+		if len(args) > 2 {
+			name = args[2]
+		}
+		inst, err := core.RegisterInstallation(name, args[1])
+		if err == core.ErrInstallationNameRequired {
+			return fmt.Errorf("%v: dbswitcher installations add %s <name>", err, args[1])
+		}
+		if err != nil {
+			return err
+		}
+		if err := core.SaveConfig(); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Registered %s (%s) at %s\n", inst.Name, inst.Version, inst.BinDir)
+
+	case "remove":
+		if len(args) < 2 {
+			return fmt.Errorf("installation name required")
+		}
+		if core.FindInstallation(args[1]) == nil {
+			return fmt.Errorf("installation '%s' is not registered", args[1])
+		}
+		core.RemoveInstallation(args[1])
+		if err := core.SaveConfig(); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Removed %s\n", args[1])
+
+	case "pin":
+		if len(args) < 2 {
+			return fmt.Errorf("configuration name required")
+		}
+		targetConfig := core.FindConfigByName(args[1])
+		if targetConfig == nil {
+			return fmt.Errorf("configuration '%s' not found", args[1])
+		}
+		name := ""
+		if len(args) > 2 {
+			name = args[2]
+		}
+		if err := core.PinInstallation(targetConfig.Name, name); err != nil {
+			return err
+		}
+		if err := core.SaveConfig(); err != nil {
+			return err
+		}
+		if name == "" {
+			fmt.Printf("✓ %s uses %s\n", targetConfig.Name, core.BinDirForConfig(*targetConfig))
+		} else {
+			fmt.Printf("✓ %s pinned to %s\n", targetConfig.Name, name)
+		}
+
+	default:
+		return fmt.Errorf("unknown installations action '%s' (use list, discover, add, remove or pin)", action)
+	}
+
+	return nil
+}
+
+// Upgrade backs up a configuration's data directory and upgrades it for the
+// configuration's server
+func (c *CLI) Upgrade(configName string) error {
+	targetConfig := core.FindConfigByName(configName)
+	if targetConfig == nil {
+		return fmt.Errorf("configuration '%s' not found", configName)
+	}
+
+	result, err := core.UpgradeDataDir(*targetConfig, core.GetCredentialsForConfig(*targetConfig), func(step string) {
+		fmt.Println(step)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Upgraded %s from %s to %s\n", result.ConfigName, result.FromVersion, result.ToVersion)
+	fmt.Printf("  Backup: %s\n", result.BackupDir)
+	if core.PathExists(result.LogPath) {
+		fmt.Printf("  Log: %s\n", result.LogPath)
+	}
+	return nil
+}
+
+// Reset replaces a configuration's data directory with a freshly initialized
+// one and starts it. The old data directory goes to the trash unless deleteData
+// is set, which asks for the configuration name to be typed first.
+func (c *CLI) Reset(configName string, deleteData bool) error {
+	targetConfig := core.FindConfigByName(configName)
+	if targetConfig == nil {
+		return fmt.Errorf("configuration '%s' not found", configName)
+	}
+	if core.IsProtectedConfig(*targetConfig) {
+		return fmt.Errorf("'%s' is protected and can't be reset", targetConfig.Name)
+	}
+
+	if deleteData {
+		fmt.Printf("This permanently deletes %s.\nType the configuration name to confirm: ", core.ResolveDataDir(*targetConfig))
+		response, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(response) != targetConfig.Name {
+			return fmt.Errorf("confirmation did not match, nothing was deleted")
+		}
+	}
+
+	result, err := core.ResetConfig(*targetConfig, deleteData, func(step string) {
+		fmt.Println(step)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Reset %s and started it on port %s\n", result.ConfigName, targetConfig.Port)
+	if result.TrashDir != "" {
+		fmt.Printf("  Old data directory: %s\n", result.TrashDir)
+	}
+	return nil
+}
+
+// RunEphemeral starts a throwaway instance, optionally from a template
+// configuration, prints how to connect to it and removes it again when the
+// server exits or the command is interrupted
+func (c *CLI) RunEphemeral(templateName string) error {
+	var template *core.MariaDBConfig
+	if templateName != "" {
+		if template = core.FindConfigByName(templateName); template == nil {
+			return fmt.Errorf("configuration '%s' not found", templateName)
+		}
+	}
+
+	// Catch interrupts from the start on, so a Ctrl+C during initialization
+	// aborts the start and cleans up too
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Println("Starting an ephemeral instance...")
+	instance, err := core.StartEphemeral(ctx, template)
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println("Interrupted, the ephemeral instance was removed.")
+			return nil
+		}
+		return err
+	}
+
+	fmt.Printf("✓ Ephemeral instance running on port %s\n", instance.Port)
+	fmt.Printf("  DSN: %s\n", instance.DSN())
+	if instance.Socket != "" {
+		fmt.Printf("  Socket: %s\n", instance.Socket)
+	}
+	fmt.Printf("  Directory: %s\n", instance.Dir)
+	fmt.Println("Press Ctrl+C to stop and remove it.")
+
+	select {
+	case <-ctx.Done():
+	case <-instance.Done():
+		fmt.Println("The server exited.")
+	}
+
+	fmt.Println("Removing the ephemeral instance...")
+	if err := instance.Destroy(); err != nil {
+		return err
+	}
+	fmt.Println("✓ Ephemeral instance removed")
+	return nil
+}
+
+// Proxy runs the TCP proxy in the foreground, following the active
+// configuration at the refresh interval until interrupted
+func (c *CLI) Proxy() error {
+	// Running the command is the opt-in, whatever the settings say
+	core.AppConfig.ProxyEnabled = true
+	core.PollStatus()
+	if err := core.StartProxy(); err != nil {
+		return err
+	}
+	defer core.StopProxy()
+
+	addr := core.AppConfig.ProxyListenAddr
+	if addr == "" {
+		addr = core.DefaultProxyListenAddr
+	}
+	fmt.Printf("Proxy listening on %s, forwarding to the active configuration.\n", addr)
+	fmt.Println("Press Ctrl+C to stop.")
+
+	interval := time.Duration(core.AppConfig.RefreshIntervalSecs) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	for {
+		select {
+		case <-interrupt:
+			fmt.Println("Proxy stopped.")
+			return nil
+		case <-ticker.C:
+			core.PollStatus()
+		}
+	}
+}
+
+// OnDemand starts on-demand configurations when a client connects to their
+// port and stops them when idle, in the foreground until interrupted
+func (c *CLI) OnDemand() error {
+	var names []string
+	for _, cfg := range core.AvailableConfigs {
+		if core.IsOnDemandConfig(cfg) {
+			names = append(names, cfg.Name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no configuration sets 'on-demand = yes' in its [dbswitcher] group")
+	}
+
+	core.StartOnDemand()
+	defer core.StopOnDemand()
+	fmt.Printf("Waiting for clients of %s.\n", strings.Join(names, ", "))
+	fmt.Println("Press Ctrl+C to stop.")
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	<-interrupt
+	fmt.Println("Stopped waiting for clients.")
+	return nil
+}
+
+// Env prints how to connect to a configuration, or to the running one when
+// configName is empty, in a connection format. The password is left out
+// unless includePassword is set.
+func (c *CLI) Env(configName, format string, includePassword bool) error {
+	targetConfig, err := configOrRunning(configName)
+	if err != nil {
+		return err
+	}
+
+	output, err := core.GetConnectionDetails(*targetConfig).Format(core.ConnectionFormat(format), includePassword)
+	if err != nil {
+		return err
+	}
+	fmt.Print(output)
+	return nil
+}
+
+// Shell runs the SQL client of a configuration, or of the running one when
+// configName is empty, logged in with the configuration's credentials
+func (c *CLI) Shell(configName string) error {
+	targetConfig, err := configOrRunning(configName)
+	if err != nil {
+		return err
+	}
+	if !core.IsConfigActive(*targetConfig, core.GetMariaDBStatus()) && !core.IsOnDemandConfig(*targetConfig) {
+		return fmt.Errorf("'%s' is not running - start it first", targetConfig.Name)
+	}
+
+	cmd, cleanup, err := core.ClientCommand(*targetConfig)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Ctrl+C is for the client, which cancels the running query
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+	// A closed terminal or a kill ends the client instead of this process, so
+	// the option file holding the password is still removed
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP, syscall.SIGTERM)
+	defer signal.Stop(hangup)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %v", filepath.Base(cmd.Path), err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case sig := <-hangup:
+			if err := cmd.Process.Signal(sig); err != nil {
+				cmd.Process.Kill()
+			}
+		case <-done:
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%s exited: %v", filepath.Base(cmd.Path), err)
+	}
+	return nil
+}
+
+// configOrRunning returns the configuration with a name, or the running one
+// when the name is empty
+func configOrRunning(configName string) (*core.MariaDBConfig, error) {
+	if configName == "" {
+		status := core.GetMariaDBStatus()
+		if !status.IsRunning {
+			return nil, fmt.Errorf("MariaDB is not running - name a configuration")
+		}
+		cfg := core.FindConfigByPath(status.ConfigFile)
+		if cfg == nil {
+			return nil, fmt.Errorf("the running server wasn't started with a known configuration")
+		}
+		return cfg, nil
+	}
+	for _, config := range core.AvailableConfigs {
+		if strings.EqualFold(config.Name, configName) {
+			return &config, nil
+		}
+	}
+	return nil, fmt.Errorf("configuration '%s' not found", configName)
+}
+
+// Use switches to the configuration named by the project file in the current
+// directory or a parent, unless it is already running, and prints the
+// project's connection environment. With export set it prints shell
+// statements for eval instead, which also unset the variables of a project
+// that was left.
+func (c *CLI) Use(export bool) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	path := core.FindProjectFile(dir)
+	if export {
+		fmt.Printf("export DBSWITCHER_PROJECT=%s\n", core.ShellQuote(path))
+	}
+	if path == "" {
+		if export {
+			printUnsetProjectVars(nil)
+			return nil
+		}
+		return fmt.Errorf("no %s found in %s or its parents", core.ProjectFileName, dir)
+	}
+
+	project, err := core.LoadProjectFile(path)
+	if err != nil {
+		return err
+	}
+	cfg, err := core.FindProjectConfig(project)
+	if err != nil {
+		return err
+	}
+
+	if !core.IsConfigActive(*cfg, core.GetMariaDBStatus()) {
+		// The shell evaluates what goes to stdout, so progress and prompts go to stderr
+		var out io.Writer = os.Stdout
+		if export {
+			out = os.Stderr
+		}
+		if err := c.Switch(cfg.Name, out); err != nil {
+			return err
+		}
+	}
+
+	env := core.ProjectEnvironment(project, *cfg)
+	if !export {
+		fmt.Printf("# %s (%s)\n", cfg.Name, path)
+		for _, v := range env {
+			fmt.Printf("%s=%s\n", v.Name, v.Value)
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(env))
+	for _, v := range env {
+		fmt.Printf("export %s=%s\n", v.Name, core.ShellQuote(v.Value))
+		names = append(names, v.Name)
+	}
+	printUnsetProjectVars(names)
+	fmt.Printf("export DBSWITCHER_VARS=%s\n", core.ShellQuote(strings.Join(names, " ")))
+	return nil
+}
+
+// printUnsetProjectVars unsets the variables a previous 'use --export' set,
+// except the ones being set again
+func printUnsetProjectVars(keep []string) {
+	for _, name := range strings.Fields(os.Getenv("DBSWITCHER_VARS")) {
+		kept := false
+		for _, k := range keep {
+			kept = kept || k == name
+		}
+		if !kept {
+			fmt.Printf("unset %s\n", name)
+		}
+	}
+	if keep == nil {
+		fmt.Println("unset DBSWITCHER_VARS")
+	}
+}
+
+// Hook prints a shell hook that runs 'use --export' whenever the shell enters
+// a directory tree with another project file
+func (c *CLI) Hook(shell string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "dbswitcher"
+	}
+
+	var register string
+	switch shell {
+	case "bash":
+		register = bashHookRegistration
+	case "zsh":
+		register = zshHookRegistration
+	default:
+		return fmt.Errorf("unsupported shell '%s' (supported: bash, zsh)", shell)
+	}
+	fmt.Printf(shellHook, core.ProjectFileName, core.ShellQuote(executable))
+	fmt.Print(register)
+	return nil
+}
+
+// shellHook finds the project file in the shell itself, so only a change of
+// project runs DBSwitcher. It works in bash and zsh.
+const shellHook = `_dbswitcher_hook() {
+  local ret=$? dir="$PWD" project=""
+  while :; do
+    if [ -f "$dir/%[1]s" ]; then
+      project="$dir/%[1]s"
+      break
+    fi
+    if [ -z "$dir" ] || [ "$dir" = "/" ]; then
+      break
+    fi
+    dir="${dir%%/*}"
+  done
+  if [ "$project" != "${DBSWITCHER_PROJECT:-}" ]; then
+    eval "$(%[2]s use --export)"
+  fi
+  return $ret
+}
+`
+
+const bashHookRegistration = `if [[ ";${PROMPT_COMMAND[*]:-};" != *";_dbswitcher_hook;"* ]]; then
+  PROMPT_COMMAND="_dbswitcher_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+const zshHookRegistration = `autoload -Uz add-zsh-hook
+add-zsh-hook precmd _dbswitcher_hook
+`
+
+// FixPerms gives a configuration's data directory to the OS user its server runs as
+func (c *CLI) FixPerms(configName string) error {
+	targetConfig := core.FindConfigByName(configName)
+	if targetConfig == nil {
+		return fmt.Errorf("configuration '%s' not found", configName)
+	}
+
+	runUser := core.ServerRunUser(*targetConfig)
+	fmt.Printf("Fixing ownership of %s for user '%s'...\n", core.ResolveDataDir(*targetConfig), runUser)
+	if err := core.FixDataDirOwnership(*targetConfig); err != nil {
+		return err
+	}
+
+	if _, err := core.PreflightCheck(*targetConfig); err != nil {
+		return fmt.Errorf("ownership was changed but the configuration still cannot start: %v", err)
+	}
+	fmt.Println("✓ Data directory ownership fixed")
+	return nil
+}
+
+// Top shows a continuously refreshing view of server health until interrupted
+func (c *CLI) Top() error {
+	interval := time.Duration(core.AppConfig.RefreshIntervalSecs) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Without ANSI support (pipes, legacy consoles) each refresh is appended
+	ansi := core.TerminalSupportsANSI(os.Stdout)
+
+	for {
+		status := core.PollStatus()
+
+		if ansi {
+			// Clear screen and move cursor to the top-left corner
+			fmt.Print("\033[H\033[2J")
+		} else {
+			fmt.Println()
+		}
+		fmt.Printf("DBSwitcher top - %s (refresh every %s, Ctrl+C to quit)\n", time.Now().Format("15:04:05"), interval)
+		fmt.Println(strings.Repeat("=", 60))
+
+		if !status.IsRunning {
+			fmt.Println("Status: ✗ STOPPED")
+		} else {
+			fmt.Printf("Status: ✓ RUNNING (PID: %d)\n", status.ProcessID)
+			fmt.Printf("Configuration: %s   Port: %s   Version: %s\n\n", status.ConfigName, status.Port, status.Version)
+
+			lines := core.FormatHealthMetrics(core.GetMetricsHistory())
+			if len(lines) == 0 {
+				if err := core.LastMetricsError(); err != nil {
+					fmt.Printf("Health metrics unavailable: %v\n", err)
+					fmt.Println("Save credentials (GUI: Tools > Credentials) to enable metrics.")
+				}
+			}
+			for _, line := range lines {
+				fmt.Println(line)
+			}
+		}
+
+		select {
+		case <-interrupt:
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// hasOwnCredentials reports whether the keyring holds credentials for a configuration
+func hasOwnCredentials(cfg *core.MariaDBConfig) bool {
+	if cfg == nil || core.IsContainerConfig(*cfg) {
+		return false
+	}
+	creds, _ := core.LoadConfigCredentials(cfg.Name)
+	return creds != nil
+}
+
+// promptForCredentials prompts the user for MySQL credentials on out
+func (c *CLI) promptForCredentials(out io.Writer) (core.MySQLCredentials, error) {
 	reader := bufio.NewReader(os.Stdin)
 	
 	// Try to use saved credentials first
 	if core.SavedCredentials != nil {
-		fmt.Printf("Use saved credentials (user: %s, host: %s)? [Y/n]: ", 
+		fmt.Fprintf(out, "Use saved credentials (user: %s, host: %s)? [Y/n]: ", 
 			core.SavedCredentials.Username, core.SavedCredentials.Host)
 		
 		response, _ := reader.ReadString('\n')
@@ -220,7 +926,7 @@ func (c *CLI) promptForCredentials() (core.MySQLCredentials, error) {
 	// Prompt for new credentials
 	creds := core.MySQLCredentials{}
 	
-	fmt.Print("MySQL Username [root]: ")
+	fmt.Fprint(out, "MySQL Username [root]: ")
 	username, _ := reader.ReadString('\n')
 	username = strings.TrimSpace(username)
 	if username == "" {
@@ -228,7 +934,7 @@ func (c *CLI) promptForCredentials() (core.MySQLCredentials, error) {
 	}
 	creds.Username = username
 	
-	fmt.Print("MySQL Host [localhost]: ")
+	fmt.Fprint(out, "MySQL Host [localhost]: ")
 	host, _ := reader.ReadString('\n')
 	host = strings.TrimSpace(host)
 	if host == "" {
@@ -236,7 +942,7 @@ func (c *CLI) promptForCredentials() (core.MySQLCredentials, error) {
 	}
 	creds.Host = host
 	
-	fmt.Print("MySQL Port [3306]: ")
+	fmt.Fprint(out, "MySQL Port [3306]: ")
 	port, _ := reader.ReadString('\n')
 	port = strings.TrimSpace(port)
 	if port == "" {
@@ -244,28 +950,28 @@ func (c *CLI) promptForCredentials() (core.MySQLCredentials, error) {
 	}
 	creds.Port = port
 	
-	fmt.Print("MySQL Password (leave empty if none): ")
+	fmt.Fprint(out, "MySQL Password (leave empty if none): ")
 	
 	// Hide password input
 	passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
 	if err != nil {
 		return creds, fmt.Errorf("failed to read password: %v", err)
 	}
-	fmt.Println() // New line after password input
+	fmt.Fprintln(out) // New line after password input
 	
 	creds.Password = string(passwordBytes)
 	
 	// Ask if user wants to save credentials
-	fmt.Print("Save credentials for future use? [y/N]: ")
+	fmt.Fprint(out, "Save credentials for future use? [y/N]: ")
 	response, _ := reader.ReadString('\n')
 	response = strings.TrimSpace(response)
 	
 	if strings.ToLower(response) == "y" || strings.ToLower(response) == "yes" {
 		if err := core.SaveCredentialsToKeyring(creds); err != nil {
-			fmt.Printf("Warning: Failed to save credentials: %v\n", err)
+			fmt.Fprintf(out, "Warning: Failed to save credentials: %v\n", err)
 		} else {
 			core.SavedCredentials = &creds
-			fmt.Println("Credentials saved securely.")
+			fmt.Fprintln(out, "Credentials saved securely.")
 		}
 	}
 	
@@ -285,6 +991,36 @@ COMMANDS:
     start <config>          Start MariaDB with specified configuration
     switch <config>         Switch to a different configuration (stops current, starts new)
     stop                    Stop the running MariaDB instance
+    du <config>             Show data directory and schema disk usage
+    top                     Show live health metrics of the running server
+    fix-perms <config>      Give the data directory to the server's OS user (needs root)
+    upgrade <config>        Back up the data directory and upgrade it for a newer server
+    env [config] [--format <f>] [--show-password]
+                            Print connection details of a configuration or the
+                            running one; formats: shell, dotenv, url, go, jdbc, cnf.
+                            The password is left out unless --show-password is given
+    shell [config]          Open the SQL client of a configuration or the
+                            running one, logged in with its credentials
+    use [--export]          Switch to the configuration named by the nearest
+                            .dbswitcher.toml and print its connection variables
+    hook <bash|zsh>         Print a shell hook that runs 'use' on cd;
+                            add eval "$(dbswitcher hook bash)" to ~/.bashrc
+    on-demand               Start on-demand configurations on their first
+                            client connection and stop them when idle
+    proxy                   Forward a fixed port to the active configuration
+                            until interrupted
+    run --ephemeral [--template <config>]
+                            Start a throwaway instance on a free port, print its
+                            DSN and remove it on exit or Ctrl+C
+    reset <config> [--delete]
+                            Move the data directory to the trash (or delete it),
+                            initialize a new one and start it
+    service <action> <config>
+                            Manage a configuration's systemd unit
+                            (install, uninstall, enable, disable, status)
+    installations [action]  Manage server installations configs can be pinned to
+                            (list, discover, add <bin-dir> [name],
+                            remove <name>, pin <config> [name])
     gui                     Launch the GUI interface
     tray                    Run in system tray mode
     help                    Show this help message
@@ -295,6 +1031,11 @@ EXAMPLES:
     dbswitcher start production        # Start with production config
     dbswitcher switch development      # Switch to development config
     dbswitcher stop                    # Stop MariaDB
+    dbswitcher du production           # Show disk usage of production config
+    dbswitcher top                     # Watch server health metrics
+    dbswitcher service enable production  # Start production at login via systemd
+    dbswitcher installations add /opt/mariadb-11.4/bin  # Register a server build
+    dbswitcher installations pin production 11.4        # Run production with it
     dbswitcher gui                     # Launch GUI
 
 CONFIGURATION:
@@ -43,6 +43,10 @@ func LoadConfig() {
 		},
 		UseServiceControl: false,
 		RequireElevation:  false,
+		SystemdServices:   false,
+		ServiceScope:      ServiceScopeUser,
+		ServiceMode:       ServiceModeUnit,
+		StopDistroService: false,
 		
 		// Default UI/Application Settings
 		AutoRefreshEnabled:    true,
@@ -52,6 +56,10 @@ func LoadConfig() {
 		AutoStartWithSystem:   false,
 		LogLevel:              "INFO",
 		
+		// Default Login Restore Settings
+		RestoreOnLogin: false,
+		MountWaitSecs:  120,
+		
 		// Default Advanced Settings
 		ProcessTimeoutSecs:    30,
 		MaxRetryAttempts:      3,
@@ -59,6 +67,18 @@ func LoadConfig() {
 		DebugMode:             false,
 		VerboseLogging:        false,
 		BackgroundProcessing:  true,
+
+		// Default Hook Settings
+		HookTimeoutSecs: 30,
+
+		// Default Metrics Endpoint Settings
+		MetricsEndpointEnabled: false,
+		MetricsListenAddr:      DefaultMetricsListenAddr,
+
+		// Default Proxy Settings
+		ProxyEnabled:    false,
+		ProxyListenAddr: DefaultProxyListenAddr,
+		ProxyDrainSecs:  10,
 	}
 
 	// Set user config directory
@@ -164,7 +184,7 @@ func ParseConfigFile(configPath string) MariaDBConfig {
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
-	inMysqldSection := false
+	section := ""
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -174,29 +194,45 @@ func ParseConfigFile(configPath string) MariaDBConfig {
 			continue
 		}
 
-		// Check for [mysqld] section
+		// Track the current [section]
 		if strings.HasPrefix(line, "[") {
-			inMysqldSection = strings.ToLower(line) == "[mysqld]"
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
 			continue
 		}
 
-		if inMysqldSection {
-			// Parse key=value pairs
-			if strings.Contains(line, "=") {
-				parts := strings.SplitN(line, "=", 2)
-				if len(parts) == 2 {
-					key := strings.TrimSpace(strings.ToLower(parts[0]))
-					value := strings.Trim(strings.TrimSpace(parts[1]), "\"'")
-
-					switch key {
-					case "datadir", "data_dir":
-						config.DataDir = value
-					case "port":
-						config.Port = value
-					case "description", "comment":
-						config.Description = value
-					}
-				}
+		// Parse key=value pairs
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(strings.ToLower(parts[0]))
+		value := strings.Trim(stripInlineComment(parts[1]), "\"'")
+
+		switch section {
+		case "mysqld":
+			switch key {
+			case "datadir", "data_dir":
+				config.DataDir = value
+			case "port":
+				config.Port = value
+			case "socket":
+				config.Socket = value
+			case "log-error", "log_error":
+				config.LogError = value
+			case "user":
+				config.User = value
+			case "basedir":
+				config.BaseDir = value
+			case "description", "comment":
+				config.Description = value
+			}
+		case "dbswitcher":
+			if config.Options == nil {
+				config.Options = map[string]string{}
+			}
+			config.Options[key] = value
+			if key == "description" || key == "comment" {
+				config.Description = value
 			}
 		}
 	}
@@ -209,6 +245,25 @@ func ParseConfigFile(configPath string) MariaDBConfig {
 	return config
 }
 
+// stripInlineComment removes a trailing # comment from an option value, as
+// the server does, leaving # inside quotes alone, and trims the value
+func stripInlineComment(value string) string {
+	var quote rune
+	for i, r := range value {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
 // EnsureConfigDirectory ensures the config directory exists and creates README
 func EnsureConfigDirectory() {
 	configDir := AppConfig.ConfigPath
@@ -258,4 +313,31 @@ func FindConfigByPath(path string) *MariaDBConfig {
 		}
 	}
 	return nil
+}
+
+// ConfigForPath returns the configuration for a config file, preferring the
+// scanned entry and falling back to parsing the file directly
+func ConfigForPath(configFile string) MariaDBConfig {
+	if cfg := FindConfigByPath(configFile); cfg != nil {
+		return *cfg
+	}
+	cfg := ParseConfigFile(configFile)
+	cfg.Name = strings.TrimSuffix(filepath.Base(configFile), filepath.Ext(configFile))
+	return cfg
+}
+
+// FindConfigByName finds a configuration by its friendly name (case-insensitive)
+func FindConfigByName(name string) *MariaDBConfig {
+	for _, config := range AvailableConfigs {
+		if strings.EqualFold(config.Name, name) {
+			return &config
+		}
+	}
+	return nil
+}
+
+// IsConfigActive checks if the given status reports a server running with the configuration
+func IsConfigActive(cfg MariaDBConfig, status MariaDBStatus) bool {
+	return status.IsRunning && status.ConfigFile != "" &&
+		filepath.Clean(status.ConfigFile) == filepath.Clean(cfg.Path)
 }
\ No newline at end of file
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ConnectionFormat is a way of writing a configuration's connection details
+type ConnectionFormat string
+
+const (
+	FormatShell      ConnectionFormat = "shell"  // export lines for POSIX shells
+	FormatDotEnv     ConnectionFormat = "dotenv" // .env file
+	FormatURL        ConnectionFormat = "url"    // DATABASE_URL
+	FormatGoDSN      ConnectionFormat = "go"     // Go MySQL driver data source name
+	FormatJDBC       ConnectionFormat = "jdbc"   // JDBC URL
+	FormatOptionFile ConnectionFormat = "cnf"    // [client] option file snippet
+)
+
+// ConnectionFormats lists the formats in the order they are offered, with a
+// label for menus
+var ConnectionFormats = []struct {
+	Format ConnectionFormat
+	Label  string
+}{
+	{FormatShell, "Shell export"},
+	{FormatDotEnv, ".env file"},
+	{FormatURL, "DATABASE_URL"},
+	{FormatGoDSN, "Go MySQL DSN"},
+	{FormatJDBC, "JDBC URL"},
+	{FormatOptionFile, "[client] option file"},
+}
+
+// ConnectionDetails is what a client needs to connect to a configuration's server
+type ConnectionDetails struct {
+	Host     string
+	Port     string
+	Socket   string // Empty when the configuration sets none
+	User     string
+	Password string
+	Database string // Empty when the configuration has no init-database
+	Flavor   string // MariaDB, MySQL or Percona
+}
+
+// GetConnectionDetails returns the connection details of a configuration with
+// its client credentials
+func GetConnectionDetails(cfg MariaDBConfig) ConnectionDetails {
+	creds := GetClientCredentials(cfg)
+	return ConnectionDetails{
+		Host:     "127.0.0.1",
+		Port:     creds.Port,
+		Socket:   cfg.Socket,
+		User:     creds.Username,
+		Password: creds.Password,
+		Database: cfg.Options["init-database"],
+		Flavor:   GetBinaryFlavor(BinDirForConfig(cfg)).Name,
+	}
+}
+
+// EnvVars returns the connection details as DB_* variables and DATABASE_URL
+func (d ConnectionDetails) EnvVars(includePassword bool) []EnvVar {
+	env := []EnvVar{{"DB_HOST", d.Host}, {"DB_PORT", d.Port}}
+	if d.Socket != "" {
+		env = append(env, EnvVar{"DB_SOCKET", d.Socket})
+	}
+	env = append(env, EnvVar{"DB_USER", d.User})
+	if includePassword {
+		env = append(env, EnvVar{"DB_PASSWORD", d.Password})
+	}
+	if d.Database != "" {
+		env = append(env, EnvVar{"DB_NAME", d.Database})
+	}
+	return append(env, EnvVar{"DATABASE_URL", d.URL(includePassword)})
+}
+
+// URL returns the details as a mysql:// URL
+func (d ConnectionDetails) URL(includePassword bool) string {
+	u := url.URL{Scheme: "mysql", Host: d.Host + ":" + d.Port, Path: "/" + d.Database}
+	if includePassword && d.Password != "" {
+		u.User = url.UserPassword(d.User, d.Password)
+	} else {
+		u.User = url.User(d.User)
+	}
+	return u.String()
+}
+
+// Format writes the connection details in a format. Without includePassword
+// the password is left out.
+func (d ConnectionDetails) Format(format ConnectionFormat, includePassword bool) (string, error) {
+	var b strings.Builder
+	switch format {
+	case FormatShell:
+		for _, v := range d.EnvVars(includePassword) {
+			fmt.Fprintf(&b, "export %s=%s\n", v.Name, ShellQuote(v.Value))
+		}
+	case FormatDotEnv:
+		for _, v := range d.EnvVars(includePassword) {
+			fmt.Fprintf(&b, "%s=%s\n", v.Name, dotEnvQuote(v.Value))
+		}
+	case FormatURL:
+		b.WriteString(d.URL(includePassword) + "\n")
+	case FormatGoDSN:
+		b.WriteString(d.User)
+		if includePassword && d.Password != "" {
+			b.WriteString(":" + d.Password)
+		}
+		fmt.Fprintf(&b, "@tcp(%s:%s)/%s\n", d.Host, d.Port, d.Database)
+	case FormatJDBC:
+		scheme := "mysql"
+		if d.Flavor == FlavorMariaDB {
+			scheme = "mariadb"
+		}
+		query := url.Values{"user": {d.User}}
+		if includePassword && d.Password != "" {
+			query.Set("password", d.Password)
+		}
+		fmt.Fprintf(&b, "jdbc:%s://%s:%s/%s?%s\n", scheme, d.Host, d.Port, d.Database, query.Encode())
+	case FormatOptionFile:
+		fmt.Fprintf(&b, "[client]\nhost = %s\nport = %s\n", d.Host, d.Port)
+		if d.Socket != "" {
+			fmt.Fprintf(&b, "socket = %s\n", d.Socket)
+		}
+		fmt.Fprintf(&b, "user = %s\n", d.User)
+		if includePassword && d.Password != "" {
+			fmt.Fprintf(&b, "password = %s\n", optionFileQuote(d.Password))
+		}
+		// Only the mysql client reads database; other tools reject it in [client]
+		if d.Database != "" {
+			fmt.Fprintf(&b, "\n[mysql]\ndatabase = %s\n", d.Database)
+		}
+	default:
+		return "", fmt.Errorf("unknown format '%s' (formats: %s)", format, connectionFormatNames())
+	}
+	return b.String(), nil
+}
+
+// connectionFormatNames lists the format names for error messages
+func connectionFormatNames() string {
+	names := make([]string, len(ConnectionFormats))
+	for i, f := range ConnectionFormats {
+		names[i] = string(f.Format)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ShellQuote quotes a value for POSIX shells
+func ShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// plainValuePattern matches values that need no quoting in .env and option files
+var plainValuePattern = regexp.MustCompile(`^[A-Za-z0-9_./:@+-]*$`)
+
+// dotEnvQuote double-quotes a .env value unless it is plain
+func dotEnvQuote(value string) string {
+	if plainValuePattern.MatchString(value) {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`).Replace(value) + `"`
+}
+
+// optionFileQuote double-quotes an option file value unless it is plain
+func optionFileQuote(value string) string {
+	if plainValuePattern.MatchString(value) {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
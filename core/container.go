@@ -0,0 +1,421 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A configuration runs in a container instead of a native mysqld when its
+// [dbswitcher] group says so:
+//
+//	runtime = container
+//	image = mariadb:11.4                 # default mariadb:lts
+//	container-env-file = dev.env          # optional, e.g. MARIADB_ROOT_PASSWORD=...
+//
+// The data directory is bind-mounted, the port published on the loopback
+// interface and the config file mounted read-only, so switching works like for
+// native instances.
+const (
+	RuntimeContainer      = "container"
+	DefaultContainerImage = "mariadb:lts"
+
+	containerDataDir    = "/var/lib/mysql"
+	containerConfigFile = "/etc/mysql/conf.d/zz-dbswitcher.cnf"
+	containerLabel      = "io.github.dbswitcher.config"
+	containerLogLines   = 500
+
+	// containerRescanInterval is how long a cached state without a watcher,
+	// or the absence of a running container, is trusted before the CLI is asked
+	// again, so changes made by another DBSwitcher process show up
+	containerRescanInterval = 30 * time.Second
+)
+
+// Container states are cached so status polls don't run the container CLI every
+// few seconds. An entry is refreshed when DBSwitcher starts or stops the
+// container. In long-running processes a "wait" watcher marks a running
+// container as exited when it stops on its own; short-lived commands don't
+// start watchers, which would outlive them.
+var (
+	containerMu       sync.Mutex
+	containerStates   = map[string]cachedContainer{}
+	containerWatched  = map[string]bool{}
+	containerScanned  time.Time
+	containerWatchers bool
+)
+
+// cachedContainer is a cached container state and when it was read
+type cachedContainer struct {
+	state   ContainerState
+	checked time.Time
+}
+
+// fresh reports whether a cached state can be used without asking the CLI.
+// The caller holds containerMu.
+func (c cachedContainer) fresh() bool {
+	return containerWatched[c.state.ID] || time.Since(c.checked) < containerRescanInterval
+}
+
+// watchContainers enables exit watchers for running containers. The supervisor
+// calls it in long-running processes.
+func watchContainers() {
+	containerMu.Lock()
+	containerWatchers = true
+	containerMu.Unlock()
+}
+
+// ContainerState is the state of a container as reported by inspect
+type ContainerState struct {
+	Name     string
+	Status   string // created, running, exited, ...
+	Running  bool
+	Pid      int
+	ExitCode int
+	Image    string
+	ID       string
+}
+
+// IsContainerConfig reports whether a configuration runs in a container
+func IsContainerConfig(cfg MariaDBConfig) bool {
+	return strings.EqualFold(cfg.Options["runtime"], RuntimeContainer)
+}
+
+// ContainerImage returns the image a container configuration runs
+func ContainerImage(cfg MariaDBConfig) string {
+	if image := cfg.Options["image"]; image != "" {
+		return image
+	}
+	return DefaultContainerImage
+}
+
+// ContainerName returns the container name used for a configuration
+func ContainerName(cfg MariaDBConfig) string {
+	return "dbswitcher-" + sanitizeName(cfg.Name)
+}
+
+// ContainerCLI returns the container CLI to use: the configured one, or docker
+// or podman from the PATH
+func ContainerCLI() (string, error) {
+	if AppConfig.ContainerCLI != "" {
+		return AppConfig.ContainerCLI, nil
+	}
+	for _, name := range []string{"docker", "podman"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("neither docker nor podman was found - install one or set the container CLI in settings")
+}
+
+// runContainerCLI runs the container CLI and returns its trimmed output
+func runContainerCLI(args ...string) (string, error) {
+	cli, err := ContainerCLI()
+	if err != nil {
+		return "", err
+	}
+
+	AppLogger.Debug("Running %s %s", cli, strings.Join(args, " "))
+	output, err := exec.Command(cli, args...).CombinedOutput()
+	out := strings.TrimSpace(string(output))
+	if err != nil {
+		if out != "" {
+			return out, fmt.Errorf("%s %s failed: %s", filepath.Base(cli), args[0], lastLine(out))
+		}
+		return out, fmt.Errorf("%s %s failed: %v", filepath.Base(cli), args[0], err)
+	}
+	return out, nil
+}
+
+// StartContainer runs a configuration's container and waits until the server
+// accepts connections. A stopped container of the same name is replaced.
+func StartContainer(cfg MariaDBConfig) error {
+	dataDir := ResolveDataDir(cfg)
+	if dataDir == "" {
+		return fmt.Errorf("container configurations need a datadir in [mysqld]")
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %v", err)
+	}
+	absConfigFile, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("cannot get absolute path for config: %v", err)
+	}
+	if cfg.LogError != "" && filepath.IsAbs(cfg.LogError) {
+		AppLogger.Warn("log-error %s is a host path and not available inside the container", cfg.LogError)
+	}
+
+	port := cfg.Port
+	if port == "" {
+		port = "3306"
+	}
+	name := ContainerName(cfg)
+
+	// The previous container is kept after a stop so its logs survive; replace it now
+	runContainerCLI("rm", "-f", name)
+
+	args := []string{"run", "-d",
+		"--name", name,
+		"--label", containerLabel + "=" + absConfigFile,
+		"-p", "127.0.0.1:" + port + ":3306",
+		"-v", dataDir + ":" + containerDataDir,
+		"-v", absConfigFile + ":" + containerConfigFile + ":ro",
+	}
+	if envFile := cfg.Options["container-env-file"]; envFile != "" {
+		if !filepath.IsAbs(envFile) {
+			envFile = filepath.Join(filepath.Dir(absConfigFile), envFile)
+		}
+		args = append(args, "--env-file", envFile)
+	} else {
+		// Needed by the image to initialize an empty data directory
+		args = append(args, "-e", "MARIADB_ALLOW_EMPTY_ROOT_PASSWORD=1")
+	}
+	// Host paths from the config file don't exist inside the container, so override them
+	args = append(args, ContainerImage(cfg),
+		"--datadir="+containerDataDir,
+		"--port=3306",
+		"--bind-address=0.0.0.0",
+		"--socket=/run/mysqld/mysqld.sock",
+		"--user=mysql",
+	)
+
+	AppLogger.Info("Starting container %s from %s", name, ContainerImage(cfg))
+	if _, err := runContainerCLI(args...); err != nil {
+		return err
+	}
+	defer refreshContainerState(name)
+
+	// A fresh data directory is initialized first, which takes longer than a native start
+	timeout := time.Duration(AppConfig.ProcessTimeoutSecs) * time.Second
+	if timeout < 60*time.Second {
+		timeout = 60 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		state, err := InspectContainer(name)
+		if err != nil {
+			return err
+		}
+		if !state.Running {
+			tail := TailFile(RefreshContainerLog(cfg), 20)
+			if tail != "" {
+				return fmt.Errorf("container exited with code %d: %s", state.ExitCode, ParseMariaDBError(tail))
+			}
+			return fmt.Errorf("container exited with code %d", state.ExitCode)
+		}
+		if pingContainer(name) {
+			RefreshContainerLog(cfg)
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+
+	RefreshContainerLog(cfg)
+	return fmt.Errorf("container %s did not accept connections on port %s within %s", name, port, timeout)
+}
+
+// StopContainer stops a configuration's container, giving the server the
+// process timeout to shut down cleanly. The container is kept for its logs.
+func StopContainer(cfg MariaDBConfig) error {
+	timeout := AppConfig.ProcessTimeoutSecs
+	if timeout <= 0 {
+		timeout = 30
+	}
+
+	name := ContainerName(cfg)
+	AppLogger.Info("Stopping container %s", name)
+	expectConfigStop(cfg, true)
+	_, err := runContainerCLI("stop", "-t", strconv.Itoa(timeout), name)
+	if err != nil {
+		expectConfigStop(cfg, false)
+	}
+	refreshContainerState(name)
+	RefreshContainerLog(cfg)
+	return err
+}
+
+// pingContainer asks the server inside a container whether it accepts TCP
+// connections. The image's first-start initialization runs a temporary server
+// without networking, so a socket ping would report it ready too early. Images
+// before MariaDB 10.5 only ship mysqladmin.
+func pingContainer(name string) bool {
+	for _, tool := range []string{"mariadb-admin", "mysqladmin"} {
+		if _, err := runContainerCLI("exec", name, tool, "--protocol=tcp", "--host=127.0.0.1", "--port=3306", "ping"); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// InspectContainer reads the state of a container
+func InspectContainer(name string) (ContainerState, error) {
+	output, err := runContainerCLI("inspect", "--format",
+		"{{.State.Status}}|{{.State.Running}}|{{.State.Pid}}|{{.State.ExitCode}}|{{.Config.Image}}|{{.Id}}", name)
+	if err != nil {
+		return ContainerState{}, err
+	}
+
+	fields := strings.Split(lastLine(output), "|")
+	if len(fields) != 6 {
+		return ContainerState{}, fmt.Errorf("unexpected inspect output: %s", output)
+	}
+	state := ContainerState{
+		Name:    name,
+		Status:  fields[0],
+		Running: fields[1] == "true",
+		Image:   fields[4],
+		ID:      fields[5],
+	}
+	state.Pid, _ = strconv.Atoi(fields[2])
+	state.ExitCode, _ = strconv.Atoi(fields[3])
+	return state, nil
+}
+
+// ContainerStatus returns the cached state of a container, inspecting it only
+// when DBSwitcher doesn't know it yet
+func ContainerStatus(name string) (ContainerState, error) {
+	containerMu.Lock()
+	entry, ok := containerStates[name]
+	ok = ok && entry.fresh()
+	containerMu.Unlock()
+	if ok {
+		return entry.state, nil
+	}
+	return refreshContainerState(name)
+}
+
+// refreshContainerState inspects a container and updates the cache. A running
+// container gets a watcher that records its exit, if watchers are enabled.
+func refreshContainerState(name string) (ContainerState, error) {
+	state, err := InspectContainer(name)
+
+	containerMu.Lock()
+	defer containerMu.Unlock()
+	if err != nil {
+		delete(containerStates, name)
+		return state, err
+	}
+	containerStates[name] = cachedContainer{state: state, checked: time.Now()}
+	if containerWatchers && state.Running && !containerWatched[state.ID] {
+		containerWatched[state.ID] = true
+		go watchContainer(state)
+	}
+	return state, nil
+}
+
+// watchContainer waits for a container to exit and records its exit code. A
+// container that was replaced in the meantime (same name, new ID) is left alone.
+func watchContainer(state ContainerState) {
+	output, err := runContainerCLI("wait", state.ID)
+
+	containerMu.Lock()
+	delete(containerWatched, state.ID)
+	entry, ok := containerStates[state.Name]
+	if !ok || entry.state.ID != state.ID {
+		containerMu.Unlock()
+		return
+	}
+	if err != nil {
+		// The CLI couldn't tell, so ask again on the next lookup
+		delete(containerStates, state.Name)
+		containerMu.Unlock()
+		return
+	}
+	exited := entry.state
+	exited.Running = false
+	exited.Status = "exited"
+	exited.Pid = 0
+	exited.ExitCode, _ = strconv.Atoi(lastLine(output))
+	containerStates[state.Name] = cachedContainer{state: exited, checked: time.Now()}
+	containerMu.Unlock()
+
+	AppLogger.Debug("Container %s exited with code %d", state.Name, exited.ExitCode)
+}
+
+// FindRunningContainer returns the container configuration that is currently
+// running, if any. It answers from the cache and only lists containers again
+// when no running container is cached and the last listing is older than
+// containerRescanInterval.
+func FindRunningContainer() (MariaDBConfig, ContainerState, bool) {
+	if !hasContainerConfigs() {
+		return MariaDBConfig{}, ContainerState{}, false
+	}
+
+	containerMu.Lock()
+	cfg, state, found := cachedRunningContainer()
+	stale := !found && time.Since(containerScanned) >= containerRescanInterval
+	containerMu.Unlock()
+	if !stale {
+		return cfg, state, found
+	}
+
+	scanContainers()
+
+	containerMu.Lock()
+	defer containerMu.Unlock()
+	return cachedRunningContainer()
+}
+
+// cachedRunningContainer looks up a running container configuration in the
+// cache. The caller holds containerMu.
+func cachedRunningContainer() (MariaDBConfig, ContainerState, bool) {
+	for _, cfg := range AvailableConfigs {
+		if !IsContainerConfig(cfg) {
+			continue
+		}
+		if entry, ok := containerStates[ContainerName(cfg)]; ok && entry.state.Running && entry.fresh() {
+			return cfg, entry.state, true
+		}
+	}
+	return MariaDBConfig{}, ContainerState{}, false
+}
+
+// scanContainers lists the running DBSwitcher containers and caches their state
+func scanContainers() {
+	output, err := runContainerCLI("ps", "--filter", "label="+containerLabel, "--format", "{{.Names}}")
+
+	containerMu.Lock()
+	containerScanned = time.Now()
+	containerMu.Unlock()
+
+	if err != nil {
+		AppLogger.Debug("Could not list containers: %v", err)
+		return
+	}
+
+	running := strings.Fields(output)
+	for _, cfg := range AvailableConfigs {
+		if IsContainerConfig(cfg) && containsString(running, ContainerName(cfg)) {
+			refreshContainerState(ContainerName(cfg))
+		}
+	}
+}
+
+// RefreshContainerLog copies the container's recent output to the configuration's
+// console log, where error log tails are read from, and returns its path
+func RefreshContainerLog(cfg MariaDBConfig) string {
+	logPath := GetConsoleLogPath(cfg.Name)
+	output, err := runContainerCLI("logs", "--tail", strconv.Itoa(containerLogLines), ContainerName(cfg))
+	if err != nil {
+		AppLogger.Debug("Could not read container logs: %v", err)
+		return logPath
+	}
+	if err := os.WriteFile(logPath, []byte(output+"\n"), 0644); err != nil {
+		AppLogger.Warn("Failed to write %s: %v", logPath, err)
+	}
+	return logPath
+}
+
+// hasContainerConfigs reports whether any configuration uses the container runtime
+func hasContainerConfigs() bool {
+	for _, cfg := range AvailableConfigs {
+		if IsContainerConfig(cfg) {
+			return true
+		}
+	}
+	return false
+}
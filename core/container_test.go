@@ -0,0 +1,139 @@
+//go:build !windows
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeContainerCLI is a docker stand-in that records its arguments in calls.log.
+// The server inside the "container" answers pings from the third exec on.
+const fakeContainerCLI = `#!/bin/sh
+dir=$(dirname "$0")
+echo "$*" >> "$dir/calls.log"
+case "$1" in
+run)
+	touch "$dir/running"
+	echo c0ffee
+	;;
+inspect)
+	if [ -f "$dir/running" ]; then
+		echo "running|true|42|0|mariadb:lts|c0ffee"
+	else
+		echo "exited|false|0|0|mariadb:lts|c0ffee"
+	fi
+	;;
+exec)
+	n=$(cat "$dir/pings" 2>/dev/null || echo 0)
+	n=$((n + 1))
+	echo $n > "$dir/pings"
+	[ $n -ge 3 ] || { echo "connect to server at '127.0.0.1' failed"; exit 1; }
+	echo "mysqld is alive"
+	;;
+stop)
+	rm -f "$dir/running"
+	;;
+wait)
+	while [ -f "$dir/running" ]; do sleep 0.1; done
+	echo 0
+	;;
+ps)
+	[ -f "$dir/running" ] && echo dbswitcher-dev
+	;;
+esac
+exit 0
+`
+
+// setupFakeContainerCLI installs the fake CLI and a container configuration
+func setupFakeContainerCLI(t *testing.T) (string, MariaDBConfig) {
+	AppLogger = &Logger{}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "share"))
+
+	cli := filepath.Join(dir, "docker")
+	if err := os.WriteFile(cli, []byte(fakeContainerCLI), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(dir, "dev.cnf")
+	os.WriteFile(cfgPath, []byte("[mysqld]\ndatadir=data\nport=33999\n\n[dbswitcher]\nruntime=container\n"), 0644)
+	cfg := ParseConfigFile(cfgPath)
+	cfg.Name = "dev"
+
+	savedConfig, savedConfigs := AppConfig, AvailableConfigs
+	t.Cleanup(func() {
+		AppConfig, AvailableConfigs = savedConfig, savedConfigs
+		containerMu.Lock()
+		containerStates = map[string]cachedContainer{}
+		containerScanned = time.Time{}
+		containerMu.Unlock()
+	})
+	AppConfig.ContainerCLI = cli
+	AvailableConfigs = []MariaDBConfig{cfg}
+	return dir, cfg
+}
+
+// fakeCalls returns the commands the fake CLI received
+func fakeCalls(t *testing.T, dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, "calls.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestStartContainerPublishesOnLoopbackAndWaitsForPing(t *testing.T) {
+	dir, cfg := setupFakeContainerCLI(t)
+
+	// Nothing listens on the port, so only the ping inside the container can report readiness
+	if err := StartContainer(cfg); err != nil {
+		t.Fatalf("StartContainer: %v", err)
+	}
+
+	var run, ping string
+	for _, call := range fakeCalls(t, dir) {
+		if strings.HasPrefix(call, "run ") {
+			run = call
+		}
+		if strings.HasPrefix(call, "exec ") {
+			ping = call
+		}
+	}
+	if !strings.Contains(run, "-p 127.0.0.1:33999:3306") {
+		t.Errorf("port not published on loopback: %s", run)
+	}
+	if !strings.Contains(run, "-v "+filepath.Join(dir, "data")+":"+containerDataDir) {
+		t.Errorf("data directory not mounted: %s", run)
+	}
+	if ping != "exec dbswitcher-dev mariadb-admin --protocol=tcp --host=127.0.0.1 --port=3306 ping" {
+		t.Errorf("last readiness check = %q", ping)
+	}
+}
+
+func TestContainerStateIsCached(t *testing.T) {
+	dir, cfg := setupFakeContainerCLI(t)
+	os.WriteFile(filepath.Join(dir, "running"), nil, 0644)
+
+	for i := 0; i < 3; i++ {
+		if _, _, found := FindRunningContainer(); !found {
+			t.Fatalf("poll %d: container not found", i)
+		}
+		if state, err := ContainerStatus(ContainerName(cfg)); err != nil || !state.Running {
+			t.Fatalf("poll %d: ContainerStatus = %+v, %v", i, state, err)
+		}
+	}
+	if calls := fakeCalls(t, dir); len(calls) != 2 {
+		t.Errorf("polls ran %d CLI commands, want one ps and one inspect: %v", len(calls), calls)
+	}
+
+	if err := StopContainer(cfg); err != nil {
+		t.Fatalf("StopContainer: %v", err)
+	}
+	if _, _, found := FindRunningContainer(); found {
+		t.Error("stopped container still reported as running")
+	}
+}
@@ -5,9 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
-	"path/filepath"
-	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/zalando/go-keyring"
@@ -75,10 +74,7 @@ func DeleteCredentialsFromKeyring() error {
 
 // TestMySQLConnection tests a MySQL connection with provided credentials
 func TestMySQLConnection(creds MySQLCredentials) error {
-	mysqlPath := filepath.Join(AppConfig.MariaDBBin, "mysql")
-	if runtime.GOOS == "windows" {
-		mysqlPath += ".exe"
-	}
+	mysqlPath := ClientToolPath()
 	
 	// Build command with credentials
 	args := []string{
@@ -132,6 +128,205 @@ func GetDefaultCredentials() MySQLCredentials {
 	}
 }
 
+// GetCredentialsForConfig returns the credentials used to talk to the server
+// started with a specific configuration: its own, when initialization generated
+// a root password for it, or the saved defaults
+func GetCredentialsForConfig(cfg MariaDBConfig) MySQLCredentials {
+	creds := GetDefaultCredentials()
+	if own, err := LoadConfigCredentials(cfg.Name); err != nil {
+		AppLogger.Error("Failed to load credentials of %s: %v", cfg.Name, err)
+	} else if own != nil {
+		creds = *own
+	}
+	if cfg.Port != "" {
+		creds.Port = cfg.Port
+	}
+	return creds
+}
+
+// GetClientCredentials returns the credentials applications use for a
+// configuration: the application user created by init-user when there is one,
+// then the root password set by initialization, and otherwise root without a
+// password. The saved default credentials are never handed out, since they
+// may belong to another server.
+func GetClientCredentials(cfg MariaDBConfig) MySQLCredentials {
+	creds := MySQLCredentials{Username: "root", Host: "localhost", Port: "3306"}
+	if own, _ := LoadConfigUserCredentials(cfg.Name); own != nil {
+		creds = *own
+	} else if own, _ := LoadConfigCredentials(cfg.Name); own != nil {
+		creds = *own
+	}
+	if cfg.Port != "" {
+		creds.Port = cfg.Port
+	}
+	return creds
+}
+
+// GetRunningCredentials returns the credentials for the running server, as
+// last seen by the status refresh
+func GetRunningCredentials() MySQLCredentials {
+	if cfg := FindConfigByPath(CurrentStatus.ConfigFile); CurrentStatus.ConfigFile != "" && cfg != nil {
+		return GetCredentialsForConfig(*cfg)
+	}
+	creds := GetDefaultCredentials()
+	if CurrentStatus.Port != "" {
+		creds.Port = CurrentStatus.Port
+	}
+	return creds
+}
+
+// Per-configuration credentials are looked up on every status refresh, so
+// keyring results are cached by account, including misses. After the keyring
+// fails (e.g. no secret service is running) it isn't asked again until
+// credentials are saved, and the failure is reported once. Configurations in
+// memoryOnlyConfigs never use the keyring; their credentials only live in the
+// cache.
+var (
+	configCredentials   = map[string]*MySQLCredentials{}
+	configKeyringErr    error
+	memoryOnlyConfigs   = map[string]bool{}
+	configCredentialsMu sync.Mutex
+)
+
+// keepCredentialsInMemory makes a configuration's credentials live only in this
+// process, for throwaway instances
+func keepCredentialsInMemory(configName string) {
+	configCredentialsMu.Lock()
+	memoryOnlyConfigs[configName] = true
+	configCredentialsMu.Unlock()
+}
+
+// credentialStore names where a configuration's credentials are saved, for messages
+func credentialStore(configName string) string {
+	configCredentialsMu.Lock()
+	defer configCredentialsMu.Unlock()
+	if memoryOnlyConfigs[configName] {
+		return "memory"
+	}
+	return "the system keyring"
+}
+
+// configCredentialsAccount is the keyring account of a configuration's admin credentials
+func configCredentialsAccount(configName string) string {
+	return "config_credentials:" + configName
+}
+
+// configUserAccount is the keyring account of the application user created
+// when a configuration's data directory was initialized
+func configUserAccount(configName string) string {
+	return "config_user:" + configName
+}
+
+// SaveConfigCredentials saves the admin credentials of one configuration
+func SaveConfigCredentials(configName string, creds MySQLCredentials) error {
+	if err := saveCachedCredentials(configName, configCredentialsAccount(configName), creds); err != nil {
+		return err
+	}
+	AppLogger.Log("Credentials of %s saved to %s", configName, credentialStore(configName))
+	return nil
+}
+
+// LoadConfigCredentials returns the admin credentials of one configuration, or
+// nil if it uses the default credentials
+func LoadConfigCredentials(configName string) (*MySQLCredentials, error) {
+	return loadCachedCredentials(configName, configCredentialsAccount(configName))
+}
+
+// DeleteConfigCredentials removes the credentials kept for one configuration
+func DeleteConfigCredentials(configName string) error {
+	configCredentialsMu.Lock()
+	delete(configCredentials, configCredentialsAccount(configName))
+	delete(configCredentials, configUserAccount(configName))
+	memoryOnly := memoryOnlyConfigs[configName]
+	delete(memoryOnlyConfigs, configName)
+	configCredentialsMu.Unlock()
+	if memoryOnly {
+		return nil
+	}
+	for _, account := range []string{configCredentialsAccount(configName), configUserAccount(configName)} {
+		if err := keyring.Delete(KeyringService, account); err != nil && err != keyring.ErrNotFound {
+			return fmt.Errorf("failed to delete from keyring: %v", err)
+		}
+	}
+	return nil
+}
+
+// SaveConfigUserCredentials saves the application user of one configuration
+func SaveConfigUserCredentials(configName string, creds MySQLCredentials) error {
+	return saveCachedCredentials(configName, configUserAccount(configName), creds)
+}
+
+// LoadConfigUserCredentials returns the application user of one configuration,
+// or nil if initialization didn't create one
+func LoadConfigUserCredentials(configName string) (*MySQLCredentials, error) {
+	return loadCachedCredentials(configName, configUserAccount(configName))
+}
+
+// loadCachedCredentials reads credentials through the cache. Only the first
+// keyring failure is returned; later lookups report no credentials.
+func loadCachedCredentials(configName, account string) (*MySQLCredentials, error) {
+	configCredentialsMu.Lock()
+	defer configCredentialsMu.Unlock()
+	if creds, ok := configCredentials[account]; ok || configKeyringErr != nil || memoryOnlyConfigs[configName] {
+		return creds, nil
+	}
+	creds, err := loadKeyringCredentials(account)
+	if err != nil {
+		configKeyringErr = err
+		return nil, err
+	}
+	configCredentials[account] = creds
+	return creds, nil
+}
+
+// saveCachedCredentials stores credentials in the keyring, unless the
+// configuration keeps them in memory, and in the cache
+func saveCachedCredentials(configName, account string, creds MySQLCredentials) error {
+	configCredentialsMu.Lock()
+	memoryOnly := memoryOnlyConfigs[configName]
+	configCredentialsMu.Unlock()
+	if !memoryOnly {
+		if err := saveKeyringCredentials(account, creds); err != nil {
+			return err
+		}
+	}
+
+	configCredentialsMu.Lock()
+	configCredentials[account] = &creds
+	if !memoryOnly {
+		configKeyringErr = nil
+	}
+	configCredentialsMu.Unlock()
+	return nil
+}
+
+// saveKeyringCredentials stores credentials as JSON under a keyring account
+func saveKeyringCredentials(account string, creds MySQLCredentials) error {
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %v", err)
+	}
+	if err := keyring.Set(KeyringService, account, string(data)); err != nil {
+		return fmt.Errorf("failed to save to keyring: %v", err)
+	}
+	return nil
+}
+
+// loadKeyringCredentials reads credentials stored under a keyring account
+func loadKeyringCredentials(account string) (*MySQLCredentials, error) {
+	data, err := keyring.Get(KeyringService, account)
+	if err == keyring.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load from keyring: %v", err)
+	}
+	var creds MySQLCredentials
+	if err := json.Unmarshal([]byte(data), &creds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal credentials: %v", err)
+	}
+	return &creds, nil
+}
+
 // SetCredentialsDefaults sets default values for empty fields
 func SetCredentialsDefaults(creds *MySQLCredentials) {
 	if creds.Username == "" {
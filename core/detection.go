@@ -9,121 +9,239 @@ import (
 	"strings"
 )
 
-// DetectMariaDBBin detects the MariaDB binary directory
+// Server flavors reported by installation discovery
+const (
+	FlavorMariaDB = "MariaDB"
+	FlavorMySQL   = "MySQL"
+	FlavorPercona = "Percona"
+)
+
+// installationTools are the binaries discovery reports for each installation
+var installationTools = []string{
+	"mariadbd", "mysqld",
+	"mariadb-install-db", "mysql_install_db",
+	"mariadb-admin", "mysqladmin",
+	"mariadb", "mysql",
+	"mariabackup", "mariadb-backup", "xtrabackup",
+}
+
+// DiscoveredInstallation is a server build found on this machine
+type DiscoveredInstallation struct {
+	Installation
+	Source string   // Where it was found: PATH, system, /opt, home or container
+	Tools  []string // Binaries available in BinDir
+	Image  string   // Container image, for Source "container"; BinDir is empty then
+}
+
+// Label describes the installation for choosers and listings
+func (d DiscoveredInstallation) Label() string {
+	if d.Image != "" {
+		return fmt.Sprintf("%s %s - image %s (container)", d.Flavor, d.Version, d.Image)
+	}
+	return fmt.Sprintf("%s %s - %s (%s)", d.Flavor, d.Version, d.BinDir, d.Source)
+}
+
+// DetectMariaDBBin detects the MariaDB binary directory. It prefers the first
+// MariaDB build found, then any other server, then the platform default.
 func DetectMariaDBBin() string {
-	// Use 'which' or 'where' command first
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("where", "mysqld.exe")
-	} else {
-		cmd = exec.Command("which", "mysqld")
-	}
-
-	if output, err := cmd.Output(); err == nil {
-		path := strings.TrimSpace(string(output))
-		if path != "" {
-			return filepath.Dir(path)
+	found := discoverNativeInstallations()
+	for _, inst := range found {
+		if inst.Flavor == FlavorMariaDB {
+			return inst.BinDir
 		}
 	}
+	if len(found) > 0 {
+		return found[0].BinDir
+	}
 
-	// Fall back to common paths
 	switch runtime.GOOS {
 	case "windows":
-		return detectWindowsMariaDB()
+		return ""
 	case "linux":
-		return detectLinuxMariaDB()
-	case "darwin":
-		return detectMacMariaDB()
-	case "freebsd":
-		return detectFreeBSDMariaDB()
+		return "/usr/bin"
 	default:
 		return "/usr/local/bin"
 	}
 }
 
-func detectWindowsMariaDB() string {
-	// Check PATH first
-	pathEnv := os.Getenv("PATH")
-	paths := strings.Split(pathEnv, ";")
-	for _, path := range paths {
-		if strings.Contains(strings.ToLower(path), "mariadb") || strings.Contains(strings.ToLower(path), "mysql") {
-			if _, err := os.Stat(filepath.Join(path, "mysqld.exe")); err == nil {
-				return path
-			}
+// DiscoverInstallations enumerates every server build on this machine: the PATH,
+// distribution and package manager directories, /opt, tarballs extracted under
+// the home directory and MariaDB/MySQL/Percona container images.
+func DiscoverInstallations() []DiscoveredInstallation {
+	return append(discoverNativeInstallations(), discoverContainerImages()...)
+}
+
+// discoverNativeInstallations probes every candidate bin directory for a server
+// binary. Directories that resolve to the same binary are reported once.
+func discoverNativeInstallations() []DiscoveredInstallation {
+	found := []DiscoveredInstallation{}
+	seen := map[string]bool{}
+
+	for _, candidate := range installationCandidates() {
+		mysqldPath, err := findServerBinary(candidate.dir)
+		if err != nil {
+			continue
 		}
-	}
+		resolved, err := filepath.EvalSymlinks(mysqldPath)
+		if err != nil {
+			resolved = mysqldPath
+		}
+		if seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
 
-	// Common installation paths
-	commonPaths := []string{
-		`C:\Program Files\MariaDB*\bin`,
-		`C:\Program Files (x86)\MariaDB*\bin`,
-		`C:\MariaDB*\bin`,
-		`C:\xampp\mysql\bin`,
-		`C:\wamp64\bin\mariadb\mariadb*\bin`,
-		`C:\wamp\bin\mysql\mysql*\bin`,
+		version, flavor := probeServerBinary(mysqldPath)
+		found = append(found, DiscoveredInstallation{
+			Installation: Installation{
+				Name:    shortVersion(version),
+				BinDir:  candidate.dir,
+				Version: version,
+				Flavor:  flavor,
+			},
+			Source: candidate.source,
+			Tools:  availableTools(candidate.dir),
+		})
 	}
+	return found
+}
 
-	for _, pattern := range commonPaths {
-		matches, _ := filepath.Glob(pattern)
-		for _, match := range matches {
-			if _, err := os.Stat(filepath.Join(match, "mysqld.exe")); err == nil {
-				return match
+type installationCandidate struct {
+	dir    string
+	source string
+}
+
+// installationCandidates lists the directories that may hold a server build, in
+// order of preference
+func installationCandidates() []installationCandidate {
+	candidates := []installationCandidate{}
+	add := func(source string, patterns ...string) {
+		for _, pattern := range patterns {
+			matches, _ := filepath.Glob(pattern)
+			for _, match := range matches {
+				candidates = append(candidates, installationCandidate{dir: match, source: source})
 			}
 		}
 	}
 
-	return ""
-}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" {
+			candidates = append(candidates, installationCandidate{dir: dir, source: "PATH"})
+		}
+	}
 
-func detectLinuxMariaDB() string {
-	paths := []string{
-		"/usr/sbin",
-		"/usr/bin",
-		"/usr/local/bin",
-		"/usr/local/mysql/bin",
-		"/opt/mariadb/bin",
-		"/opt/mysql/bin",
+	switch runtime.GOOS {
+	case "windows":
+		add("system",
+			`C:\Program Files\MariaDB*\bin`,
+			`C:\Program Files (x86)\MariaDB*\bin`,
+			`C:\Program Files\MySQL\MySQL Server*\bin`,
+			`C:\MariaDB*\bin`,
+			`C:\xampp\mysql\bin`,
+			`C:\wamp64\bin\mariadb\mariadb*\bin`,
+			`C:\wamp64\bin\mysql\mysql*\bin`,
+			`C:\wamp\bin\mysql\mysql*\bin`,
+		)
+	case "linux":
+		add("system", "/usr/sbin", "/usr/bin", "/usr/local/bin", "/usr/local/sbin", "/usr/libexec", "/usr/local/mysql/bin")
+	case "darwin":
+		add("system",
+			"/usr/local/mysql/bin",
+			"/opt/homebrew/bin",
+			"/opt/homebrew/opt/*/bin",
+			"/usr/local/opt/*/bin",
+			"/usr/local/bin",
+			"/opt/local/bin",
+			"/opt/local/lib/mariadb*/bin",
+			"/Applications/MAMP/Library/bin",
+		)
+	case "freebsd":
+		add("system", "/usr/local/libexec", "/usr/local/bin", "/usr/local/mysql/bin")
 	}
 
-	for _, path := range paths {
-		if _, err := os.Stat(filepath.Join(path, "mysqld")); err == nil {
-			return path
-		}
+	if runtime.GOOS != "windows" {
+		add("/opt", "/opt/*/bin", "/opt/*/*/bin", "/usr/local/*/bin")
 	}
-	return "/usr/bin"
-}
 
-func detectMacMariaDB() string {
-	paths := []string{
-		"/usr/local/mysql/bin",
-		"/opt/homebrew/bin",
-		"/opt/homebrew/opt/mariadb/bin",
-		"/usr/local/bin",
-		"/opt/local/bin",
-		"/Applications/MAMP/Library/bin",
+	if home, err := os.UserHomeDir(); err == nil {
+		add("home",
+			filepath.Join(home, "*", "bin"),
+			filepath.Join(home, "opt", "*", "bin"),
+			filepath.Join(home, "Downloads", "*", "bin"),
+			filepath.Join(home, ".local", "opt", "*", "bin"),
+		)
 	}
 
-	for _, path := range paths {
-		if _, err := os.Stat(filepath.Join(path, "mysqld")); err == nil {
-			return path
+	return candidates
+}
+
+// availableTools returns the known server and client tools present in a bin directory
+func availableTools(binDir string) []string {
+	tools := []string{}
+	for _, tool := range installationTools {
+		if PathExists(filepath.Join(binDir, GetExecutableName(tool))) {
+			tools = append(tools, tool)
 		}
 	}
-	return "/usr/local/bin"
+	return tools
 }
 
-func detectFreeBSDMariaDB() string {
-	paths := []string{
-		"/usr/local/libexec",
-		"/usr/local/bin",
-		"/usr/local/mysql/bin",
+// discoverContainerImages lists local MariaDB, MySQL and Percona images. It
+// returns nothing if no container CLI is installed.
+func discoverContainerImages() []DiscoveredInstallation {
+	if _, err := ContainerCLI(); err != nil {
+		return nil
+	}
+	output, err := runContainerCLI("images", "--format", "{{.Repository}}:{{.Tag}}")
+	if err != nil {
+		AppLogger.Debug("Could not list container images: %v", err)
+		return nil
 	}
 
-	for _, path := range paths {
-		if _, err := os.Stat(filepath.Join(path, "mysqld")); err == nil {
-			return path
+	found := []DiscoveredInstallation{}
+	seen := map[string]bool{}
+	for _, image := range strings.Fields(output) {
+		if strings.HasSuffix(image, ":<none>") || seen[image] {
+			continue
+		}
+		repository, tag := image, "latest"
+		if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+			repository, tag = image[:i], image[i+1:]
+		}
+
+		flavor := ""
+		switch name := strings.ToLower(filepath.Base(repository)); {
+		case strings.Contains(name, "mariadb"):
+			flavor = FlavorMariaDB
+		case strings.Contains(name, "percona"):
+			flavor = FlavorPercona
+		case strings.Contains(name, "mysql"):
+			flavor = FlavorMySQL
+		default:
+			continue
 		}
+
+		seen[image] = true
+		found = append(found, DiscoveredInstallation{
+			Installation: Installation{Name: tag, Version: tag, Flavor: flavor},
+			Source:       "container",
+			Image:        image,
+		})
+	}
+	return found
+}
+
+// serverFlavor classifies a server from its --version output
+func serverFlavor(versionOutput string) string {
+	lower := strings.ToLower(versionOutput)
+	switch {
+	case strings.Contains(lower, "mariadb"):
+		return FlavorMariaDB
+	case strings.Contains(lower, "percona"):
+		return FlavorPercona
+	default:
+		return FlavorMySQL
 	}
-	return "/usr/local/bin"
 }
 
 // IsDriveRemovable checks if a Windows drive is removable
@@ -0,0 +1,320 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dataDirUsageTTL is how long a computed usage report is reused before the
+// data directory is walked again
+const dataDirUsageTTL = 60 * time.Second
+
+// Schema size sources
+const (
+	SchemaSourceFilesystem        = "filesystem"
+	SchemaSourceInformationSchema = "information_schema"
+)
+
+// SchemaUsage represents the size of a single schema (database)
+type SchemaUsage struct {
+	Name      string `json:"name"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// DataDirUsage represents disk usage information for a configuration's data directory
+type DataDirUsage struct {
+	ConfigName     string        `json:"config_name"`
+	DataDir        string        `json:"data_dir"`
+	TotalBytes     int64         `json:"total_bytes"`
+	FreeBytes      int64         `json:"free_bytes"` // -1 when unknown
+	InnoDBLogBytes int64         `json:"innodb_log_bytes"`
+	UndoBytes      int64         `json:"undo_bytes"`
+	LastModified   time.Time     `json:"last_modified"`
+	Schemas        []SchemaUsage `json:"schemas"`
+	SchemaSource   string        `json:"schema_source"` // filesystem or information_schema
+	ComputedAt     time.Time     `json:"computed_at"`
+}
+
+type dataDirUsageEntry struct {
+	usage   *DataDirUsage
+	running bool
+}
+
+var (
+	dataDirUsageCache   = map[string]dataDirUsageEntry{}
+	dataDirUsageCacheMu sync.Mutex
+)
+
+// GetDataDirUsage returns the disk usage of a configuration's data directory,
+// reusing a cached result while it is fresh and the running state is unchanged
+func GetDataDirUsage(cfg MariaDBConfig, running bool) (*DataDirUsage, error) {
+	dataDir := ResolveDataDir(cfg)
+
+	dataDirUsageCacheMu.Lock()
+	entry, ok := dataDirUsageCache[dataDir]
+	dataDirUsageCacheMu.Unlock()
+
+	if ok && entry.running == running && time.Since(entry.usage.ComputedAt) < dataDirUsageTTL {
+		AppLogger.Debug("Using cached disk usage for %s", dataDir)
+		return entry.usage, nil
+	}
+
+	return RefreshDataDirUsage(cfg, running)
+}
+
+// RefreshDataDirUsage computes the disk usage of a configuration's data directory
+// and stores it in the cache. Schema sizes come from information_schema when the
+// server is running and from the schema directories otherwise.
+func RefreshDataDirUsage(cfg MariaDBConfig, running bool) (*DataDirUsage, error) {
+	dataDir := ResolveDataDir(cfg)
+	if dataDir == "" {
+		return nil, fmt.Errorf("configuration '%s' has no data directory", cfg.Name)
+	}
+
+	AppLogger.Debug("Computing disk usage for %s", dataDir)
+
+	usage, schemaDirs, err := scanDataDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	usage.ConfigName = cfg.Name
+
+	if free, err := GetFreeDiskSpace(dataDir); err != nil {
+		AppLogger.Debug("Could not determine free space for %s: %v", dataDir, err)
+		usage.FreeBytes = -1
+	} else {
+		usage.FreeBytes = free
+	}
+
+	usage.Schemas = schemaDirs
+	usage.SchemaSource = SchemaSourceFilesystem
+	if running {
+		if schemas, err := querySchemaSizes(GetCredentialsForConfig(cfg)); err != nil {
+			AppLogger.Debug("Falling back to directory sizes for %s: %v", cfg.Name, err)
+		} else {
+			usage.Schemas = schemas
+			usage.SchemaSource = SchemaSourceInformationSchema
+		}
+	}
+
+	usage.ComputedAt = time.Now()
+
+	dataDirUsageCacheMu.Lock()
+	dataDirUsageCache[dataDir] = dataDirUsageEntry{usage: usage, running: running}
+	dataDirUsageCacheMu.Unlock()
+
+	return usage, nil
+}
+
+// CachedDataDirUsage returns the last computed usage for a configuration
+// without touching the filesystem, or nil if none has been computed yet
+func CachedDataDirUsage(cfg MariaDBConfig) *DataDirUsage {
+	dataDirUsageCacheMu.Lock()
+	defer dataDirUsageCacheMu.Unlock()
+
+	if entry, ok := dataDirUsageCache[ResolveDataDir(cfg)]; ok {
+		return entry.usage
+	}
+	return nil
+}
+
+// InvalidateDataDirUsage drops any cached usage for a data directory
+func InvalidateDataDirUsage(dataDir string) {
+	dataDirUsageCacheMu.Lock()
+	delete(dataDirUsageCache, filepath.Clean(dataDir))
+	dataDirUsageCacheMu.Unlock()
+}
+
+// ResolveDataDir returns the absolute data directory of a configuration.
+// Relative paths are resolved against the config file's directory, matching
+// the behavior of StartMariaDBWithConfig.
+func ResolveDataDir(cfg MariaDBConfig) string {
+	dataDir := cfg.DataDir
+	if dataDir == "" {
+		dataDir = GetDefaultDataDir()
+	}
+	if dataDir == "" {
+		return ""
+	}
+	if !filepath.IsAbs(dataDir) && cfg.Path != "" {
+		if absConfig, err := filepath.Abs(cfg.Path); err == nil {
+			dataDir = filepath.Join(filepath.Dir(absConfig), dataDir)
+		}
+	}
+	return filepath.Clean(dataDir)
+}
+
+// scanDataDir walks a data directory once and collects totals, InnoDB log and
+// undo sizes, the newest modification time and per-schema directory sizes
+func scanDataDir(dataDir string) (*DataDirUsage, []SchemaUsage, error) {
+	info, err := os.Stat(dataDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot access data directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, nil, fmt.Errorf("data directory is not a directory: %s", dataDir)
+	}
+
+	usage := &DataDirUsage{DataDir: dataDir, LastModified: info.ModTime()}
+	schemaSizes := map[string]int64{}
+
+	err = filepath.WalkDir(dataDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			// Unreadable entries (e.g. files owned by the mysql user) are skipped
+			AppLogger.Debug("Skipping %s: %v", path, walkErr)
+			if d != nil && d.IsDir() && path != dataDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dataDir, path)
+		topLevel := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+
+		if d.IsDir() {
+			if path != dataDir && rel == topLevel && isSchemaDirName(topLevel) {
+				if _, ok := schemaSizes[topLevel]; !ok {
+					schemaSizes[topLevel] = 0
+				}
+			}
+			return nil
+		}
+
+		fileInfo, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		size := fileInfo.Size()
+		usage.TotalBytes += size
+		if fileInfo.ModTime().After(usage.LastModified) {
+			usage.LastModified = fileInfo.ModTime()
+		}
+
+		name := strings.ToLower(d.Name())
+		switch {
+		case strings.HasPrefix(name, "ib_logfile") || topLevel == "#innodb_redo":
+			usage.InnoDBLogBytes += size
+		case isUndoFileName(name):
+			usage.UndoBytes += size
+		}
+
+		if rel != topLevel && isSchemaDirName(topLevel) {
+			schemaSizes[topLevel] += size
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan data directory: %v", err)
+	}
+
+	schemas := make([]SchemaUsage, 0, len(schemaSizes))
+	for name, size := range schemaSizes {
+		schemas = append(schemas, SchemaUsage{Name: name, SizeBytes: size})
+	}
+	sortSchemaUsage(schemas)
+
+	return usage, schemas, nil
+}
+
+// isSchemaDirName reports whether a top-level data directory entry is a schema
+func isSchemaDirName(name string) bool {
+	return name != "" && name != "." && !strings.HasPrefix(name, "#") && name != "lost+found"
+}
+
+// isUndoFileName reports whether a file is an InnoDB undo tablespace
+// (undo001 in MariaDB, undo_001 or *.ibu in MySQL)
+func isUndoFileName(name string) bool {
+	if strings.HasSuffix(name, ".ibu") {
+		return true
+	}
+	if !strings.HasPrefix(name, "undo") {
+		return false
+	}
+	_, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(name, "undo"), "_"))
+	return err == nil
+}
+
+// querySchemaSizes asks the running server for per-schema data and index sizes
+func querySchemaSizes(creds MySQLCredentials) ([]SchemaUsage, error) {
+	rows, err := ExecMySQLQueryRows(
+		"SELECT table_schema, COALESCE(SUM(data_length + index_length), 0) "+
+			"FROM information_schema.tables GROUP BY table_schema", creds)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := []SchemaUsage{}
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		size, _ := strconv.ParseInt(row[1], 10, 64)
+		schemas = append(schemas, SchemaUsage{Name: row[0], SizeBytes: size})
+	}
+	sortSchemaUsage(schemas)
+	return schemas, nil
+}
+
+// sortSchemaUsage orders schemas by size, largest first
+func sortSchemaUsage(schemas []SchemaUsage) {
+	sort.Slice(schemas, func(i, j int) bool {
+		if schemas[i].SizeBytes == schemas[j].SizeBytes {
+			return schemas[i].Name < schemas[j].Name
+		}
+		return schemas[i].SizeBytes > schemas[j].SizeBytes
+	})
+}
+
+// GetFreeDiskSpace returns the free space in bytes on the filesystem holding path
+func GetFreeDiskSpace(path string) (int64, error) {
+	// Walk up to the nearest existing directory so unmounted or not yet
+	// created data directories still report their parent filesystem
+	for !PathExists(path) {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return 0, fmt.Errorf("no existing parent directory for %s", path)
+		}
+		path = parent
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd := exec.Command("powershell", "-NoProfile", "-Command",
+			fmt.Sprintf("(Get-Item -LiteralPath '%s').PSDrive.Free", strings.ReplaceAll(path, "'", "''")))
+		output, err := cmd.Output()
+		if err != nil {
+			return 0, fmt.Errorf("failed to query drive: %v", err)
+		}
+		return strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	default:
+		// POSIX output format: Filesystem 1024-blocks Used Available Capacity Mounted on
+		cmd := exec.Command("df", "-Pk", path)
+		output, err := cmd.Output()
+		if err != nil {
+			return 0, fmt.Errorf("df failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		if len(lines) < 2 {
+			return 0, fmt.Errorf("unexpected df output: %s", string(output))
+		}
+		fields := strings.Fields(lines[len(lines)-1])
+		if len(fields) < 4 {
+			return 0, fmt.Errorf("unexpected df output: %s", string(output))
+		}
+		availableKB, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected df output: %s", string(output))
+		}
+		return availableKB * 1024, nil
+	}
+}
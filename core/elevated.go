@@ -1,25 +1,623 @@
 package core
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
-// RunElevated runs a command with elevated privileges
-func RunElevated(name string, args ...string) error {
-	switch runtime.GOOS {
-	case "windows":
-		// Use PowerShell to run as administrator
-		psCmd := fmt.Sprintf("Start-Process '%s' -ArgumentList '%s' -Verb RunAs -Wait",
-			name, strings.Join(args, "','"))
-		cmd := exec.Command("powershell", "-Command", psCmd)
-		return cmd.Run()
+// HelperCommand is the hidden subcommand that performs privileged operations.
+// Only this subcommand is ever run as root, never arbitrary commands.
+const HelperCommand = "elevated-helper"
+
+// PolkitActionID is the polkit action shipped in packaging/linux for pkexec prompts
+const PolkitActionID = "io.github.ahmedaredah.dbswitcher.helper"
+
+// Operations accepted by the elevated helper
+const (
+	HelperSystemctl     = "systemctl"      // systemctl <action> [--now] <unit>
+	HelperWriteUnit     = "write-unit"     // write-unit <path>, unit content on stdin
+	HelperRemoveUnit    = "remove-unit"    // remove-unit <path>
+	HelperFixDataDir    = "fix-datadir"    // fix-datadir <config-file> <user>
+	HelperBackupDataDir = "backup-datadir" // backup-datadir <config-file> <backup-dir>
+)
+
+// maxUnitSize bounds the unit content accepted on stdin
+const maxUnitSize = 16 * 1024
+
+var (
+	// graphicalElevation selects pkexec prompts (GUI) over non-interactive sudo (CLI)
+	graphicalElevation bool
+
+	helperUnitPattern   = regexp.MustCompile(`^dbswitcher-[a-z0-9_-]+\.service$`)
+	helperDistroUnits   = []string{"mariadb.service", "mysql.service", "mysqld.service"}
+	helperSystemdDir    = "/etc/systemd/system"
+	helperUnitKeys      = []string{"Description", "After", "Type", "ExecStart", "WorkingDirectory", "TimeoutStartSec", "TimeoutStopSec", "Restart", "LimitNOFILE", "WantedBy", "User", "Group"}
+	helperSystemActions = []string{"start", "stop", "restart", "enable", "disable"}
+
+	// helperExecStartPattern is the only ExecStart= GenerateServiceUnit writes
+	helperExecStartPattern = regexp.MustCompile(`^"((?:[^"\\]|\\.)*)" --defaults-file="((?:[^"\\]|\\.)*)"$`)
+	// helperServerDirs hold packaged server binaries, usable without registering an installation
+	helperServerDirs = []string{"/usr/sbin", "/usr/bin", "/usr/libexec", "/usr/local/sbin", "/usr/local/bin"}
+	// helperDataRoots may hold data directories below them, besides the user's home
+	// directory and /var/lib/mysql* and /var/lib/mariadb*
+	helperDataRoots = []string{"/srv", "/opt", "/data"}
+	// helperDataDirMarkers are files one of which an initialized data directory has
+	helperDataDirMarkers = []string{"mysql", "ibdata1", "aria_log_control", "mysql_upgrade_info", "auto.cnf"}
+	// helperServerAccounts are the accounts servers run as, besides the user
+	helperServerAccounts = []string{"mysql", "mariadb", "_mysql"}
+	// helperBackupSuffix is the only backup name UpgradeDataDir uses
+	helperBackupSuffix = regexp.MustCompile(`^\.pre-upgrade-[0-9]{8}-[0-9]{6}$`)
+)
+
+// UseGraphicalElevation makes privileged operations prompt through pkexec instead
+// of failing when sudo would ask for a password. The GUI calls this at startup.
+func UseGraphicalElevation() {
+	graphicalElevation = true
+}
+
+// IsRoot reports whether this process runs with root privileges
+func IsRoot() bool {
+	return os.Geteuid() == 0
+}
+
+// ElevationReasons lists what about starting a configuration requires root
+// privileges the current user does not have. It is empty on Windows and for root.
+func ElevationReasons(cfg MariaDBConfig) []string {
+	if runtime.GOOS == "windows" || IsRoot() {
+		return nil
+	}
+
+	reasons := []string{}
+	if port, err := strconv.Atoi(cfg.Port); err == nil && port > 0 && port < 1024 {
+		reasons = append(reasons, fmt.Sprintf("port %d is below 1024 and can only be bound by root", port))
+	}
+	if dataDir := ResolveDataDir(cfg); dataDir != "" && PathExists(dataDir) && !IsDirWritable(dataDir) {
+		reasons = append(reasons, fmt.Sprintf("data directory %s is not writable by the current user", dataDir))
+	}
+	if UseSystemdServices() && isSystemScope() {
+		reasons = append(reasons, "system-scope systemd units are managed by root")
+	}
+	return reasons
+}
+
+// CheckStartPrivileges returns a descriptive error if a configuration listens on
+// a port the current user cannot bind. System-scope services are started by
+// systemd as root and are not affected. Data directory ownership is checked by
+// PreflightCheck.
+func CheckStartPrivileges(cfg MariaDBConfig) error {
+	if runtime.GOOS == "windows" || IsRoot() {
+		return nil
+	}
+	if UseSystemdServices() && isSystemScope() {
+		return nil
+	}
+
+	if port, err := strconv.Atoi(cfg.Port); err == nil && port > 0 && port < 1024 {
+		return fmt.Errorf("port %d is below 1024 and can only be bound by root; use a higher port or run the configuration as a system-scope systemd service", port)
+	}
+	return nil
+}
+
+// runElevatedHelper runs one helper operation as root: in-process when already
+// root, through pkexec in the GUI and through sudo -n otherwise
+func runElevatedHelper(stdin io.Reader, op string, args ...string) error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("elevated operations are not supported on Windows; run DBSwitcher as administrator")
+	}
+
+	if IsRoot() {
+		return RunElevatedHelper(append([]string{op}, args...), stdin)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the DBSwitcher executable: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	helperArgs := append([]string{exe, HelperCommand, op}, args...)
+
+	var cmd *exec.Cmd
+	if graphicalElevation {
+		if _, err := exec.LookPath("pkexec"); err != nil {
+			return fmt.Errorf("root privileges are required but pkexec is not installed; install polkit or run the command from a terminal with sudo")
+		}
+		cmd = exec.Command("pkexec", helperArgs...)
+	} else {
+		cmd = exec.Command("sudo", append([]string{"-n"}, helperArgs...)...)
+	}
+	cmd.Stdin = stdin
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	AppLogger.Info("Running elevated helper: %s %s", op, strings.Join(args, " "))
+
+	err = cmd.Run()
+	if err == nil {
+		return nil
+	}
+
+	msg := strings.TrimSpace(stderr.String())
+	if exitErr, ok := err.(*exec.ExitError); ok && graphicalElevation {
+		// pkexec exits with 126 when the dialog is dismissed and 127 when not authorized
+		switch exitErr.ExitCode() {
+		case 126:
+			return fmt.Errorf("authentication was cancelled")
+		case 127:
+			return fmt.Errorf("not authorized to %s", describeHelperOperation(op, args))
+		}
+	}
+	if !graphicalElevation && strings.Contains(msg, "password is required") {
+		return fmt.Errorf("%s requires root privileges: run 'sudo -v' first or run the command with sudo", describeHelperOperation(op, args))
+	}
+	if msg != "" {
+		return fmt.Errorf("%v: %s", err, lastLine(msg))
+	}
+	return err
+}
+
+// describeHelperOperation returns a short description of an operation for error messages
+func describeHelperOperation(op string, args []string) string {
+	switch op {
+	case HelperSystemctl:
+		return "systemctl " + strings.Join(args, " ")
+	case HelperWriteUnit, HelperRemoveUnit:
+		if len(args) > 0 {
+			return "modify " + args[0]
+		}
+	case HelperFixDataDir:
+		if len(args) > 0 {
+			return "change ownership of the data directory of " + args[0]
+		}
+	case HelperBackupDataDir:
+		if len(args) > 0 {
+			return "back up the data directory of " + args[0]
+		}
+	}
+	return op
+}
+
+// RunElevatedHelper performs a privileged operation after validating its
+// arguments. It runs as root and does not use the application settings; only
+// the configurations and installations registered by the user who requested
+// the elevation are read, to check arguments against.
+func RunElevatedHelper(args []string, stdin io.Reader) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no helper operation given")
+	}
+	if !IsRoot() {
+		return fmt.Errorf("the elevated helper must run as root")
+	}
+
+	op, args := args[0], args[1:]
+	switch op {
+	case HelperSystemctl:
+		return helperSystemctl(args)
+	case HelperWriteUnit:
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s <path>", op)
+		}
+		invoker, err := loadHelperInvoker()
+		if err != nil {
+			return err
+		}
+		return helperWriteUnit(args[0], stdin, invoker)
+	case HelperRemoveUnit:
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s <path>", op)
+		}
+		return helperRemoveUnit(args[0])
+	case HelperFixDataDir:
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s <config-file> <user>", op)
+		}
+		invoker, err := loadHelperInvoker()
+		if err != nil {
+			return err
+		}
+		return helperFixDataDir(args[0], args[1], invoker)
+	case HelperBackupDataDir:
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s <config-file> <backup-dir>", op)
+		}
+		invoker, err := loadHelperInvoker()
+		if err != nil {
+			return err
+		}
+		return helperBackupDataDir(args[0], args[1], invoker)
 	default:
-		// Unix systems use sudo
-		allArgs := append([]string{name}, args...)
-		cmd := exec.Command("sudo", allArgs...)
-		return cmd.Run()
+		return fmt.Errorf("unknown helper operation '%s'", op)
+	}
+}
+
+// helperInvoker is the user who requested an elevated operation, with what
+// they registered in DBSwitcher
+type helperInvoker struct {
+	uid      string
+	name     string
+	group    string // Name of the primary group
+	home     string
+	settings Config
+}
+
+// loadHelperInvoker looks up the user who ran sudo or pkexec and reads their
+// settings. Their home directory comes from the user database, not the
+// environment.
+func loadHelperInvoker() (*helperInvoker, error) {
+	u, err := user.LookupId(invokingUID())
+	if err != nil {
+		return nil, fmt.Errorf("cannot look up the invoking user: %v", err)
+	}
+	invoker := &helperInvoker{uid: u.Uid, name: u.Username, home: filepath.Clean(u.HomeDir)}
+	if group, err := user.LookupGroupId(u.Gid); err == nil {
+		invoker.group = group.Name
+	}
+
+	dataDir := filepath.Join(invoker.home, ".local", "share")
+	if runtime.GOOS == "darwin" {
+		dataDir = filepath.Join(invoker.home, "Library", "Application Support")
+	}
+	if data, err := os.ReadFile(filepath.Join(dataDir, "DBSwitcher", "settings.json")); err == nil {
+		if err := json.Unmarshal(data, &invoker.settings); err != nil {
+			return nil, fmt.Errorf("cannot read the settings of %s: %v", invoker.name, err)
+		}
+	}
+	return invoker, nil
+}
+
+// registeredConfig parses a configuration file of the invoking user. Only files
+// in their configuration directory are accepted.
+func (h *helperInvoker) registeredConfig(path string) (MariaDBConfig, error) {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return MariaDBConfig{}, fmt.Errorf("configuration path must be absolute and clean: %s", path)
+	}
+	dirs := []string{filepath.Join(h.home, ".config", "DBSwitcher")}
+	if h.settings.ConfigPath != "" {
+		dirs = append(dirs, filepath.Clean(h.settings.ConfigPath))
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if !containsString(dirs, filepath.Dir(path)) || (ext != ".cnf" && ext != ".ini") {
+		return MariaDBConfig{}, fmt.Errorf("%s is not a registered configuration", path)
+	}
+	if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+		return MariaDBConfig{}, fmt.Errorf("%s is not a registered configuration", path)
+	}
+	return ParseConfigFile(path), nil
+}
+
+// isRegisteredBinDir reports whether a directory holds a server the invoking
+// user registered, or a packaged one
+func (h *helperInvoker) isRegisteredBinDir(dir string) bool {
+	if containsString(helperServerDirs, dir) {
+		return true
+	}
+	if h.settings.MariaDBBin != "" && filepath.Clean(h.settings.MariaDBBin) == dir {
+		return true
+	}
+	for _, inst := range h.settings.Installations {
+		if filepath.Clean(inst.BinDir) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// helperSystemctl runs daemon-reload or an action on a DBSwitcher or distro MariaDB unit
+func helperSystemctl(args []string) error {
+	if len(args) == 1 && args[0] == "daemon-reload" {
+		return runCommandWithOutput("systemctl", "daemon-reload")
+	}
+
+	cmdArgs := []string{}
+	if len(args) == 3 && args[1] == "--now" && (args[0] == "enable" || args[0] == "disable") {
+		cmdArgs = append(cmdArgs, args[0], "--now")
+		args = []string{args[0], args[2]}
+	}
+	if len(args) != 2 || !containsString(helperSystemActions, args[0]) {
+		return fmt.Errorf("usage: %s <%s> [--now] <unit>", HelperSystemctl, strings.Join(helperSystemActions, "|"))
+	}
+	if len(cmdArgs) == 0 {
+		cmdArgs = append(cmdArgs, args[0])
+	}
+
+	unit := args[1]
+	if !strings.HasSuffix(unit, ".service") {
+		unit += ".service"
+	}
+	if !helperUnitPattern.MatchString(unit) && !containsString(helperDistroUnits, unit) {
+		return fmt.Errorf("unit %s is not managed by DBSwitcher", unit)
+	}
+	return runCommandWithOutput("systemctl", append(cmdArgs, unit)...)
+}
+
+// validateUnitPath accepts only DBSwitcher units and drop-ins for the distro service
+func validateUnitPath(path string) error {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return fmt.Errorf("unit path must be absolute and clean: %s", path)
+	}
+
+	dir, name := filepath.Split(path)
+	dir = filepath.Clean(dir)
+	if dir == helperSystemdDir && helperUnitPattern.MatchString(name) {
+		return nil
+	}
+	if name == dropInFileName && filepath.Dir(dir) == helperSystemdDir &&
+		containsString(helperDistroUnits, strings.TrimSuffix(filepath.Base(dir), ".d")) {
+		return nil
+	}
+	return fmt.Errorf("%s is not a DBSwitcher unit file", path)
+}
+
+// helperWriteUnit writes a unit or drop-in after checking it only contains the
+// keys DBSwitcher generates and starts a registered server with a registered
+// configuration
+func helperWriteUnit(path string, stdin io.Reader, invoker *helperInvoker) error {
+	if err := validateUnitPath(path); err != nil {
+		return err
+	}
+	if stdin == nil {
+		return fmt.Errorf("no unit content on stdin")
+	}
+
+	content, err := io.ReadAll(io.LimitReader(stdin, maxUnitSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read unit content: %v", err)
+	}
+	if len(content) > maxUnitSize {
+		return fmt.Errorf("unit content exceeds %d bytes", maxUnitSize)
+	}
+	if err := validateUnitContent(string(content), invoker); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// validateUnitContent checks every setting of a unit against the allowed keys.
+// The unit must run the server as the invoking user and their primary group:
+// they control the configuration file, which can load plugins and run SQL
+// files, so running it as root or the distro service's user would hand them
+// that account.
+func validateUnitContent(content string, invoker *helperInvoker) error {
+	var runsAsUser, runsAsGroup bool
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			switch line {
+			case "[Unit]", "[Service]", "[Install]":
+				continue
+			}
+			return fmt.Errorf("unit section %s is not allowed", line)
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !containsString(helperUnitKeys, key) {
+			return fmt.Errorf("unit setting '%s' is not allowed", line)
+		}
+		switch key {
+		case "ExecStart":
+			if value != "" {
+				if err := validateExecStart(value, invoker); err != nil {
+					return err
+				}
+			}
+		case "User":
+			if value != invoker.name {
+				return fmt.Errorf("the unit must run as %s, not '%s'", invoker.name, value)
+			}
+			runsAsUser = true
+		case "Group":
+			if invoker.group == "" || value != invoker.group {
+				return fmt.Errorf("the unit must run with group %s, not '%s'", invoker.group, value)
+			}
+			runsAsGroup = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !runsAsUser || !runsAsGroup {
+		return fmt.Errorf("the unit must set User=%s and Group=%s", invoker.name, invoker.group)
+	}
+	return nil
+}
+
+// validateExecStart requires the command GenerateServiceUnit writes: mysqld or
+// mariadbd of a registered installation, run with a registered configuration
+func validateExecStart(value string, invoker *helperInvoker) error {
+	match := helperExecStartPattern.FindStringSubmatch(value)
+	if match == nil {
+		return fmt.Errorf("ExecStart must be a quoted server path and --defaults-file, got %s", value)
+	}
+	unquote := strings.NewReplacer(`\\`, `\`, `\"`, `"`, "%%", "%")
+	binary, configFile := unquote.Replace(match[1]), unquote.Replace(match[2])
+
+	base := filepath.Base(binary)
+	if !filepath.IsAbs(binary) || filepath.Clean(binary) != binary || (base != "mysqld" && base != "mariadbd") {
+		return fmt.Errorf("ExecStart must run an absolute mysqld or mariadbd path, got %s", binary)
+	}
+	if !invoker.isRegisteredBinDir(filepath.Dir(binary)) {
+		return fmt.Errorf("%s is not in a registered installation", binary)
+	}
+
+	if _, err := invoker.registeredConfig(configFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// helperRemoveUnit removes a unit or drop-in written by helperWriteUnit
+func helperRemoveUnit(path string) error {
+	if err := validateUnitPath(path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %v", path, err)
+	}
+	return nil
+}
+
+// registeredDataDir returns a registered configuration with its data
+// directory, which must be a real directory, not reached through a symlink, in
+// an allowed location and either empty or an initialized data directory
+func (h *helperInvoker) registeredDataDir(configFile string) (MariaDBConfig, string, error) {
+	cfg, err := h.registeredConfig(configFile)
+	if err != nil {
+		return cfg, "", err
+	}
+	if cfg.DataDir == "" {
+		return cfg, "", fmt.Errorf("%s doesn't set a datadir", configFile)
+	}
+	dir := cfg.DataDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(configFile), dir)
+	}
+	dir = filepath.Clean(dir)
+
+	if resolved, err := filepath.EvalSymlinks(dir); err != nil {
+		return cfg, "", fmt.Errorf("cannot access %s: %v", dir, err)
+	} else if resolved != dir {
+		return cfg, "", fmt.Errorf("refusing to use %s: it is reached through a symlink", dir)
+	}
+	if !isAllowedDataDirLocation(dir, h.home) {
+		return cfg, "", fmt.Errorf("refusing to use %s: data directories must be in your home directory, /var/lib/mysql*, /var/lib/mariadb* or below %s",
+			dir, strings.Join(helperDataRoots, ", "))
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return cfg, "", fmt.Errorf("cannot access %s: %v", dir, err)
+	}
+	if !info.IsDir() {
+		return cfg, "", fmt.Errorf("%s is not a directory", dir)
+	}
+	if !looksLikeDataDir(dir) {
+		return cfg, "", fmt.Errorf("refusing to use %s: it is neither empty nor a data directory", dir)
+	}
+	return cfg, dir, nil
+}
+
+// helperBackupDataDir copies a registered configuration's data directory to a
+// new <datadir>.pre-upgrade-<timestamp> directory next to it, keeping owners
+// and permissions
+func helperBackupDataDir(configFile, backupDir string, invoker *helperInvoker) error {
+	_, dir, err := invoker.registeredDataDir(configFile)
+	if err != nil {
+		return err
+	}
+	if backupDir != filepath.Clean(backupDir) || filepath.Dir(backupDir) != filepath.Dir(dir) ||
+		!strings.HasPrefix(filepath.Base(backupDir), filepath.Base(dir)) ||
+		!helperBackupSuffix.MatchString(strings.TrimPrefix(filepath.Base(backupDir), filepath.Base(dir))) {
+		return fmt.Errorf("backups of %s must be named %s.pre-upgrade-<timestamp>, not %s", dir, dir, backupDir)
+	}
+	if _, err := os.Lstat(backupDir); err == nil {
+		return fmt.Errorf("%s already exists", backupDir)
+	}
+	return backupDataDir(dir, backupDir)
+}
+
+// helperFixDataDir recursively gives the data directory of a registered
+// configuration to a user and makes sure the user can read and write it. The
+// new owner must be the configuration's user= or, without one, the user who
+// requested the elevation; never root. The data directory must not be reached
+// through a symlink, must be in the user's home directory or a directory meant
+// for data, and must look like a data directory.
+func helperFixDataDir(configFile, userName string, invoker *helperInvoker) error {
+	cfg, dir, err := invoker.registeredDataDir(configFile)
+	if err != nil {
+		return err
+	}
+
+	// Services run as the invoking user; user= applies when DBSwitcher runs as root
+	if userName != invoker.name && userName != cfg.User {
+		return fmt.Errorf("%s doesn't run as '%s', so its data directory can't be given to it", filepath.Base(configFile), userName)
+	}
+	target, err := user.Lookup(userName)
+	if err != nil {
+		return fmt.Errorf("unknown user '%s'", userName)
+	}
+	uid, _ := strconv.Atoi(target.Uid)
+	gid, _ := strconv.Atoi(target.Gid)
+	if uid == 0 {
+		return fmt.Errorf("refusing to give %s to root", dir)
+	}
+	if target.Uid != invoker.uid && !containsString(helperServerAccounts, target.Username) {
+		return fmt.Errorf("ownership can only be given to yourself or the %s account, not '%s'",
+			strings.Join(helperServerAccounts, "/"), userName)
+	}
+
+	return changeDataDirOwner(dir, uid, gid)
+}
+
+// invokingUID returns the uid of the user who ran sudo or pkexec
+func invokingUID() string {
+	if uid := os.Getenv("PKEXEC_UID"); uid != "" {
+		return uid
+	}
+	if uid := os.Getenv("SUDO_UID"); uid != "" {
+		return uid
+	}
+	return strconv.Itoa(os.Getuid())
+}
+
+// isAllowedDataDirLocation reports whether a data directory is somewhere the
+// helper may change ownership: below the user's home directory or one of
+// helperDataRoots, or in /var/lib/mysql* or /var/lib/mariadb*
+func isAllowedDataDirLocation(dir, home string) bool {
+	roots := append([]string{home}, helperDataRoots...)
+	for _, root := range roots {
+		if root != "" && root != "/" && strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	if rel, err := filepath.Rel("/var/lib", dir); err == nil && !strings.HasPrefix(rel, "..") {
+		first := strings.Split(rel, string(filepath.Separator))[0]
+		return strings.HasPrefix(first, "mysql") || strings.HasPrefix(first, "mariadb")
+	}
+	return false
+}
+
+// looksLikeDataDir reports whether a directory is empty or has a file only
+// data directories have
+func looksLikeDataDir(dir string) bool {
+	if empty, err := IsDirEmpty(dir); err == nil && empty {
+		return true
+	}
+	for _, marker := range helperDataDirMarkers {
+		if _, err := os.Lstat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
-}
\ No newline at end of file
+	return false
+}
@@ -0,0 +1,168 @@
+//go:build !windows
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// changeDataDirOwner gives a data directory and everything in it to uid and
+// gid and adds missing owner read/write permissions. The user can write to the
+// directory while this runs as root, so every entry is reached through a file
+// descriptor opened without following symlinks and changed through that
+// descriptor: swapping a path component for a symlink can't redirect a change
+// outside the directory. Files with other hard links are refused, since they
+// may be outside it too; all are checked before anything is changed and again
+// when each is changed. The filesystem's lost+found stays with root.
+func changeDataDirOwner(dir string, uid, gid int) error {
+	for _, apply := range []bool{false, true} {
+		fd, err := openDirNoFollow(dir)
+		if err != nil {
+			return err
+		}
+		walker := &ownerWalker{uid: uid, gid: gid, apply: apply}
+		err = walker.walk(fd, dir, true)
+		unix.Close(fd)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openDirNoFollow opens an absolute directory path one component at a time,
+// refusing symlinks anywhere along it
+func openDirNoFollow(path string) (int, error) {
+	fd, err := unix.Open("/", unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, fmt.Errorf("cannot open /: %v", err)
+	}
+	for _, name := range strings.Split(strings.Trim(filepath.Clean(path), "/"), "/") {
+		if name == "" {
+			continue
+		}
+		next, err := unix.Openat(fd, name, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		unix.Close(fd)
+		if err != nil {
+			return -1, fmt.Errorf("cannot open %s without following symlinks: %v", path, err)
+		}
+		fd = next
+	}
+	return fd, nil
+}
+
+// ownerWalker checks, and with apply set changes, the entries below a
+// directory descriptor
+type ownerWalker struct {
+	uid, gid int
+	apply    bool
+}
+
+func (w *ownerWalker) walk(dirFd int, path string, top bool) error {
+	if err := w.fix(dirFd, path, 0700); err != nil {
+		return err
+	}
+
+	names, err := readDirNames(dirFd, path)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		entryPath := filepath.Join(path, name)
+		var st unix.Stat_t
+		if err := unix.Fstatat(dirFd, name, &st, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+			return fmt.Errorf("cannot access %s: %v", entryPath, err)
+		}
+
+		switch st.Mode & unix.S_IFMT {
+		case unix.S_IFDIR:
+			if top && name == "lost+found" {
+				continue
+			}
+			fd, err := unix.Openat(dirFd, name, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+			if err != nil {
+				return fmt.Errorf("cannot open %s: %v", entryPath, err)
+			}
+			err = w.walk(fd, entryPath, false)
+			unix.Close(fd)
+			if err != nil {
+				return err
+			}
+		case unix.S_IFREG:
+			// O_NONBLOCK keeps a FIFO swapped in meanwhile from blocking; fix
+			// checks the type again on the descriptor
+			fd, err := unix.Openat(dirFd, name, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+			if err != nil {
+				return fmt.Errorf("cannot open %s: %v", entryPath, err)
+			}
+			err = w.fix(fd, entryPath, 0600)
+			unix.Close(fd)
+			if err != nil {
+				return err
+			}
+		default:
+			// Symlinks, sockets and the like only change owner, never their target
+			if w.apply {
+				if err := unix.Fchownat(dirFd, name, w.uid, w.gid, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+					return fmt.Errorf("cannot change the owner of %s: %v", entryPath, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// fix checks an open directory or regular file and, with apply set, changes
+// its owner and adds the required owner permission bits
+func (w *ownerWalker) fix(fd int, path string, required uint32) error {
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("cannot access %s: %v", path, err)
+	}
+	switch st.Mode & unix.S_IFMT {
+	case unix.S_IFDIR:
+	case unix.S_IFREG:
+		if st.Nlink > 1 {
+			return fmt.Errorf("refusing to change ownership: %s has other hard links", path)
+		}
+	default:
+		return fmt.Errorf("%s changed while its ownership was being fixed", path)
+	}
+	if !w.apply {
+		return nil
+	}
+
+	if err := unix.Fchown(fd, w.uid, w.gid); err != nil {
+		return fmt.Errorf("cannot change the owner of %s: %v", path, err)
+	}
+	// Changing the owner clears setuid and setgid bits, so read the mode again
+	if err := unix.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("cannot access %s: %v", path, err)
+	}
+	if mode := uint32(st.Mode) & 07777; mode&required != required {
+		if err := unix.Fchmod(fd, mode|required); err != nil {
+			return fmt.Errorf("cannot change the permissions of %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// readDirNames lists a directory through its descriptor
+func readDirNames(fd int, path string) ([]string, error) {
+	dup, err := unix.Dup(fd)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", path, err)
+	}
+	dir := os.NewFile(uintptr(dup), path)
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", path, err)
+	}
+	return names, nil
+}
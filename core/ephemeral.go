@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ephemeralPortStart is where the search for a free ephemeral port begins,
+// above the default port so a regular instance can keep it
+const ephemeralPortStart = 3307
+
+// EphemeralInstance is a throwaway server whose option file, data directory,
+// socket and logs all live in one temporary directory
+type EphemeralInstance struct {
+	Config MariaDBConfig
+	Dir    string
+	Port   string
+	Socket string // Empty on Windows
+
+	process *os.Process
+	done    chan struct{}
+}
+
+// StartEphemeral starts a throwaway instance on a free port with a new data
+// directory under tmpfs when available. With a template configuration it uses
+// the template's server, settings, init-* options and seed files, but not its
+// hooks. Credentials generated by initialization are kept in memory only.
+// Cancelling ctx aborts the start and removes the instance; otherwise the
+// caller destroys it with Destroy.
+func StartEphemeral(ctx context.Context, template *MariaDBConfig) (*EphemeralInstance, error) {
+	if template != nil && IsContainerConfig(*template) {
+		return nil, fmt.Errorf("container configurations can't be used as ephemeral templates")
+	}
+
+	port := ""
+	for p := ephemeralPortStart; p < ephemeralPortStart+1000; p++ {
+		if IsPortAvailable(strconv.Itoa(p)) {
+			port = strconv.Itoa(p)
+			break
+		}
+	}
+	if port == "" {
+		return nil, fmt.Errorf("no free port found from %d", ephemeralPortStart)
+	}
+
+	dir, err := os.MkdirTemp(ephemeralBaseDir(), "dbswitcher-ephemeral-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a temporary directory: %v", err)
+	}
+	instance := &EphemeralInstance{Dir: dir, Port: port, done: make(chan struct{})}
+	if runtime.GOOS != "windows" {
+		instance.Socket = filepath.Join(dir, "mysqld.sock")
+	}
+
+	name := "ephemeral-" + port
+	configFile := filepath.Join(dir, name+".cnf")
+	if err := os.WriteFile(configFile, []byte(ephemeralOptionFile(template, instance)), 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write option file: %v", err)
+	}
+	instance.Config = ConfigForPath(configFile)
+	keepCredentialsInMemory(instance.Config.Name)
+
+	err = StartMariaDBWithOptions(configFile, StartOptions{
+		Ephemeral: true,
+		Cancel:    ctx.Done(),
+		OnProcess: func(process *os.Process) {
+			instance.process = process
+			go func() {
+				process.Wait()
+				close(instance.done)
+			}()
+		},
+	})
+	if err != nil {
+		instance.Destroy()
+		return nil, err
+	}
+	return instance, nil
+}
+
+// ephemeralBaseDir returns a memory-backed temporary directory when there is
+// one, so ephemeral data never touches the disk
+func ephemeralBaseDir() string {
+	if runtime.GOOS == "linux" {
+		if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+			if probe, err := os.MkdirTemp("/dev/shm", "dbswitcher-probe-"); err == nil {
+				os.Remove(probe)
+				return "/dev/shm"
+			}
+		}
+	}
+	return os.TempDir()
+}
+
+// ephemeralOptionFile returns the option file of an ephemeral instance: the
+// template's file, if any, followed by groups that override everything tied
+// to the template's own instance. Later settings win in option files.
+func ephemeralOptionFile(template *MariaDBConfig, instance *EphemeralInstance) string {
+	var b strings.Builder
+	if template != nil {
+		if data, err := os.ReadFile(template.Path); err == nil {
+			fmt.Fprintf(&b, "# Template: %s\n%s\n", template.Path, strings.TrimRight(string(data), "\n"))
+		}
+	}
+
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("[mysqld]\n")
+	fmt.Fprintf(&b, "port = %s\n", instance.Port)
+	fmt.Fprintf(&b, "bind-address = 127.0.0.1\n")
+	fmt.Fprintf(&b, "datadir = %s\n", filepath.ToSlash(filepath.Join(instance.Dir, "data")))
+	fmt.Fprintf(&b, "pid-file = %s\n", filepath.ToSlash(filepath.Join(instance.Dir, "mysqld.pid")))
+	fmt.Fprintf(&b, "log-error = %s\n", filepath.ToSlash(filepath.Join(instance.Dir, "error.log")))
+	if instance.Socket != "" {
+		fmt.Fprintf(&b, "socket = %s\n", instance.Socket)
+	}
+	// The data directory belongs to whoever runs DBSwitcher
+	if runtime.GOOS != "windows" && os.Geteuid() == 0 {
+		fmt.Fprintf(&b, "user = root\n")
+	}
+
+	fmt.Fprintf(&b, "\n[dbswitcher]\ndescription = Ephemeral instance\n")
+	if template != nil {
+		// Resolved here, since they are relative to the template's directory
+		fmt.Fprintf(&b, "bin = %s\n", BinDirForConfig(*template))
+		if opts, err := InitOptionsForConfig(*template, GetBinaryFlavor(BinDirForConfig(*template))); err == nil && opts.SeedDir != "" {
+			fmt.Fprintf(&b, "init-seed-dir = %s\n", opts.SeedDir)
+		}
+	}
+	return b.String()
+}
+
+// Credentials returns the application user created by init-user when there is
+// one, then the root password set by initialization, and otherwise root
+// without a password. Saved credentials of other servers are never used.
+func (e *EphemeralInstance) Credentials() MySQLCredentials {
+	creds := GetClientCredentials(e.Config)
+	creds.Port = e.Port
+	return creds
+}
+
+// DSN returns a connection URL for the instance with its Credentials
+func (e *EphemeralInstance) DSN() string {
+	creds := e.Credentials()
+	dsn := url.URL{Scheme: "mysql", Host: "127.0.0.1:" + e.Port, Path: "/" + e.Config.Options["init-database"]}
+	if creds.Password != "" {
+		dsn.User = url.UserPassword(creds.Username, creds.Password)
+	} else {
+		dsn.User = url.User(creds.Username)
+	}
+	return dsn.String()
+}
+
+// Done is closed when the instance's server exits
+func (e *EphemeralInstance) Done() <-chan struct{} {
+	return e.done
+}
+
+// Destroy stops the server and removes the instance's directory, console log
+// and credentials
+func (e *EphemeralInstance) Destroy() error {
+	if e.process != nil {
+		select {
+		case <-e.done:
+		default:
+			// The data is thrown away, but a clean shutdown releases the socket and port
+			if err := terminateProcess(e.process); err != nil {
+				e.process.Kill()
+			}
+			select {
+			case <-e.done:
+			case <-time.After(time.Duration(AppConfig.ProcessTimeoutSecs) * time.Second):
+				e.process.Kill()
+				<-e.done
+			}
+		}
+	}
+
+	if e.Config.Name != "" {
+		os.Remove(GetConsoleLogPath(e.Config.Name))
+		if err := DeleteConfigCredentials(e.Config.Name); err != nil {
+			AppLogger.Warn("Failed to remove the credentials of %s: %v", e.Config.Name, err)
+		}
+	}
+	if err := os.RemoveAll(e.Dir); err != nil {
+		return fmt.Errorf("failed to remove %s: %v", e.Dir, err)
+	}
+	AppLogger.Info("Ephemeral instance on port %s destroyed", e.Port)
+	return nil
+}
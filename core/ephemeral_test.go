@@ -0,0 +1,41 @@
+package core
+
+import "testing"
+
+func TestEphemeralCredentialsStayInMemory(t *testing.T) {
+	AppLogger = &Logger{}
+
+	saved := SavedCredentials
+	defer func() { SavedCredentials = saved }()
+	SavedCredentials = &MySQLCredentials{Username: "admin", Password: "saved", Host: "localhost", Port: "3306"}
+
+	instance := &EphemeralInstance{Config: MariaDBConfig{Name: "ephemeral-test"}, Port: "3399"}
+	keepCredentialsInMemory(instance.Config.Name)
+	defer DeleteConfigCredentials(instance.Config.Name)
+
+	// Without initialization credentials the saved defaults must not leak in
+	if creds := instance.Credentials(); creds.Username != "root" || creds.Password != "" || creds.Port != "3399" {
+		t.Errorf("credentials = %+v, want root without a password on 3399", creds)
+	}
+
+	// No keyring is available here, so these only succeed in memory
+	if err := SaveConfigCredentials(instance.Config.Name, MySQLCredentials{Username: "root", Password: "generated"}); err != nil {
+		t.Fatalf("saving the root password: %v", err)
+	}
+	if creds := instance.Credentials(); creds.Username != "root" || creds.Password != "generated" {
+		t.Errorf("credentials = %+v, want the generated root password", creds)
+	}
+	if err := SaveConfigUserCredentials(instance.Config.Name, MySQLCredentials{Username: "app", Password: "apppw"}); err != nil {
+		t.Fatalf("saving the application user: %v", err)
+	}
+	if creds := instance.Credentials(); creds.Username != "app" || creds.Password != "apppw" || creds.Port != "3399" {
+		t.Errorf("credentials = %+v, want the application user", creds)
+	}
+
+	if err := DeleteConfigCredentials(instance.Config.Name); err != nil {
+		t.Fatalf("DeleteConfigCredentials: %v", err)
+	}
+	if creds, _ := LoadConfigUserCredentials(instance.Config.Name); creds != nil {
+		t.Errorf("application user survived Destroy: %+v", creds)
+	}
+}
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMetricsListenAddr is the default address of the Prometheus endpoint.
+// It is bound to localhost so metrics are not exposed to the network by accident.
+const DefaultMetricsListenAddr = "127.0.0.1:9290"
+
+// instanceCounters holds per-configuration lifecycle counters
+type instanceCounters struct {
+	Starts             int64
+	Stops              int64
+	LastSwitchDuration time.Duration
+}
+
+var (
+	instanceStats   = map[string]*instanceCounters{}
+	instanceStatsMu sync.Mutex
+
+	metricsServer     *http.Server
+	metricsServerAddr string // Address metricsServer listens on
+	metricsServerMu   sync.Mutex
+	metricsHookOnce   sync.Once
+)
+
+// RecordInstanceStart counts a successful start and how long it took until the
+// server accepted connections
+func RecordInstanceStart(configName string, duration time.Duration) {
+	instanceStatsMu.Lock()
+	defer instanceStatsMu.Unlock()
+
+	counters := instanceCountersFor(configName)
+	counters.Starts++
+	counters.LastSwitchDuration = duration
+}
+
+// RecordInstanceStop counts a stop of the server running with a configuration
+func RecordInstanceStop(configName string) {
+	instanceStatsMu.Lock()
+	defer instanceStatsMu.Unlock()
+
+	instanceCountersFor(configName).Stops++
+}
+
+// instanceCountersFor returns the counters of a configuration, creating them if needed.
+// The caller must hold instanceStatsMu.
+func instanceCountersFor(configName string) *instanceCounters {
+	if configName == "" {
+		configName = "unknown"
+	}
+	counters, ok := instanceStats[configName]
+	if !ok {
+		counters = &instanceCounters{}
+		instanceStats[configName] = counters
+	}
+	return counters
+}
+
+// StartMetricsEndpoint starts the Prometheus HTTP listener if it is enabled in settings
+func StartMetricsEndpoint() error {
+	if !AppConfig.MetricsEndpointEnabled {
+		AppLogger.Debug("Metrics endpoint is disabled")
+		return nil
+	}
+
+	metricsServerMu.Lock()
+	defer metricsServerMu.Unlock()
+
+	if metricsServer != nil {
+		return nil
+	}
+
+	// Keep the datadir size of the active configuration fresh on each status poll.
+	// The usage cache makes this a no-op most of the time.
+	metricsHookOnce.Do(func() {
+		OnStatusPolled(func(status MariaDBStatus) {
+			if !AppConfig.MetricsEndpointEnabled || !status.IsRunning || status.ConfigFile == "" {
+				return
+			}
+			if cfg := FindConfigByPath(status.ConfigFile); cfg != nil {
+				if _, err := GetDataDirUsage(*cfg, true); err != nil {
+					AppLogger.Debug("Metrics: could not compute datadir usage for %s: %v", cfg.Name, err)
+				}
+			}
+		})
+	})
+
+	addr := metricsListenAddr()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WritePrometheusMetrics(w)
+	})
+
+	metricsServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	metricsServerAddr = addr
+
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			AppLogger.Error("Metrics endpoint stopped: %v", err)
+		}
+	}(metricsServer)
+
+	AppLogger.Info("Prometheus metrics endpoint listening on http://%s/metrics", listener.Addr())
+	return nil
+}
+
+// StopMetricsEndpoint stops the Prometheus HTTP listener if it is running
+func StopMetricsEndpoint() {
+	metricsServerMu.Lock()
+	defer metricsServerMu.Unlock()
+
+	if metricsServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := metricsServer.Shutdown(ctx); err != nil {
+		AppLogger.Warn("Metrics endpoint shutdown failed: %v", err)
+	}
+	metricsServer = nil
+	metricsServerAddr = ""
+	AppLogger.Info("Prometheus metrics endpoint stopped")
+}
+
+// RestartMetricsEndpoint applies changed endpoint settings. The endpoint is
+// left alone if they didn't change.
+func RestartMetricsEndpoint() error {
+	metricsServerMu.Lock()
+	running, addr := metricsServer != nil, metricsServerAddr
+	metricsServerMu.Unlock()
+	if running == AppConfig.MetricsEndpointEnabled && (!running || addr == metricsListenAddr()) {
+		return nil
+	}
+
+	StopMetricsEndpoint()
+	return StartMetricsEndpoint()
+}
+
+// metricsListenAddr returns the address the endpoint should listen on
+func metricsListenAddr() string {
+	if AppConfig.MetricsListenAddr == "" {
+		return DefaultMetricsListenAddr
+	}
+	return AppConfig.MetricsListenAddr
+}
+
+// WritePrometheusMetrics writes all metrics in the Prometheus text exposition format.
+// Values come from the last status poll, so scraping never queries the server.
+func WritePrometheusMetrics(w io.Writer) {
+	status := CurrentStatus
+	configs := append([]MariaDBConfig{}, AvailableConfigs...)
+
+	writeMetricHeader(w, "dbswitcher_instance_up", "gauge", "Whether the server is running with the configuration (1) or not (0).")
+	for _, cfg := range configs {
+		up := 0
+		if IsConfigActive(cfg, status) {
+			up = 1
+		}
+		fmt.Fprintf(w, "dbswitcher_instance_up{config=\"%s\"} %d\n", escapeLabelValue(cfg.Name), up)
+	}
+
+	instanceStatsMu.Lock()
+	names := make([]string, 0, len(instanceStats))
+	for name := range instanceStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stats := make(map[string]instanceCounters, len(instanceStats))
+	for name, counters := range instanceStats {
+		stats[name] = *counters
+	}
+	instanceStatsMu.Unlock()
+
+	writeMetricHeader(w, "dbswitcher_instance_starts_total", "counter", "Number of starts performed by this process.")
+	for _, name := range names {
+		fmt.Fprintf(w, "dbswitcher_instance_starts_total{config=\"%s\"} %d\n", escapeLabelValue(name), stats[name].Starts)
+	}
+
+	writeMetricHeader(w, "dbswitcher_instance_stops_total", "counter", "Number of stops performed by this process.")
+	for _, name := range names {
+		fmt.Fprintf(w, "dbswitcher_instance_stops_total{config=\"%s\"} %d\n", escapeLabelValue(name), stats[name].Stops)
+	}
+
+	writeMetricHeader(w, "dbswitcher_last_switch_duration_seconds", "gauge", "Time the last start took until the server accepted connections.")
+	for _, name := range names {
+		if stats[name].Starts > 0 {
+			fmt.Fprintf(w, "dbswitcher_last_switch_duration_seconds{config=\"%s\"} %s\n",
+				escapeLabelValue(name), formatMetricValue(stats[name].LastSwitchDuration.Seconds()))
+		}
+	}
+
+	writeMetricHeader(w, "dbswitcher_datadir_size_bytes", "gauge", "Total size of the data directory.")
+	for _, cfg := range configs {
+		if usage := CachedDataDirUsage(cfg); usage != nil {
+			fmt.Fprintf(w, "dbswitcher_datadir_size_bytes{config=\"%s\"} %d\n", escapeLabelValue(cfg.Name), usage.TotalBytes)
+		}
+	}
+
+	writeMetricHeader(w, "dbswitcher_datadir_free_bytes", "gauge", "Free space on the filesystem holding the data directory.")
+	for _, cfg := range configs {
+		if usage := CachedDataDirUsage(cfg); usage != nil && usage.FreeBytes >= 0 {
+			fmt.Fprintf(w, "dbswitcher_datadir_free_bytes{config=\"%s\"} %d\n", escapeLabelValue(cfg.Name), usage.FreeBytes)
+		}
+	}
+
+	// Server status counters are only available when credentials work
+	latest := LatestHealthMetrics()
+	if latest == nil || !status.IsRunning {
+		return
+	}
+	config := escapeLabelValue(latest.ConfigName)
+
+	writeMetricHeader(w, "dbswitcher_server_queries_per_second", "gauge", "Queries per second between the last two polls.")
+	fmt.Fprintf(w, "dbswitcher_server_queries_per_second{config=\"%s\"} %s\n", config, formatMetricValue(latest.QPS))
+
+	if latest.BufferPoolHitRatio >= 0 {
+		writeMetricHeader(w, "dbswitcher_server_buffer_pool_hit_ratio", "gauge", "InnoDB buffer pool hit ratio in percent.")
+		fmt.Fprintf(w, "dbswitcher_server_buffer_pool_hit_ratio{config=\"%s\"} %s\n", config, formatMetricValue(latest.BufferPoolHitRatio))
+	}
+
+	writeMetricHeader(w, "dbswitcher_server_global_status", "untyped", "Numeric values from SHOW GLOBAL STATUS.")
+	variables := make([]string, 0, len(latest.Status))
+	for name := range latest.Status {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	for _, name := range variables {
+		value, err := strconv.ParseFloat(strings.TrimSpace(latest.Status[name]), 64)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(w, "dbswitcher_server_global_status{config=\"%s\",variable=\"%s\"} %s\n",
+			config, escapeLabelValue(strings.ToLower(name)), formatMetricValue(value))
+	}
+}
+
+// writeMetricHeader writes the HELP and TYPE lines of a metric family
+func writeMetricHeader(w io.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// escapeLabelValue escapes a string for use as a Prometheus label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatMetricValue formats a float without unnecessary precision
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
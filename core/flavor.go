@@ -0,0 +1,352 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// dataDirServerFile records which server last ran a data directory on its first
+// line, e.g. "MariaDB 10.11.6-MariaDB", and which version it was last initialized
+// or upgraded for on an optional "upgraded <version>" line. The server ignores
+// files it doesn't know in its data directory.
+const dataDirServerFile = "dbswitcher_server_info"
+
+// ServerFlavor describes the naming and behavior of a server family
+type ServerFlavor struct {
+	Name string // MariaDB, MySQL or Percona
+
+	// Binary names, preferred first. MariaDB 11 deprecates the mysql* names.
+	ServerBinaries    []string
+	InstallDBBinaries []string // Empty when the server initializes itself
+	AdminBinaries     []string
+	ClientBinaries    []string
+	UpgradeBinaries   []string
+	TimezoneBinaries  []string // Convert the zoneinfo database to SQL
+
+	// DefaultDataDirs is the packaged data directory per GOOS
+	DefaultDataDirs map[string]string
+
+	// FullDowngradeCheck refuses downgrades between patch releases too, for
+	// servers that don't support any in-place downgrade
+	FullDowngradeCheck bool
+
+	// family groups flavors that share an on-disk format
+	family string
+}
+
+var (
+	mariaDBFlavor = &ServerFlavor{
+		Name:              FlavorMariaDB,
+		ServerBinaries:    []string{"mariadbd", "mysqld"},
+		InstallDBBinaries: []string{"mariadb-install-db", "mysql_install_db"},
+		AdminBinaries:     []string{"mariadb-admin", "mysqladmin"},
+		ClientBinaries:    []string{"mariadb", "mysql"},
+		UpgradeBinaries:   []string{"mariadb-upgrade", "mysql_upgrade"},
+		TimezoneBinaries:  []string{"mariadb-tzinfo-to-sql", "mysql_tzinfo_to_sql"},
+		DefaultDataDirs: map[string]string{
+			"windows": `C:\Program Files\MariaDB\data`,
+			"linux":   "/var/lib/mysql",
+			"darwin":  "/usr/local/var/mysql",
+			"freebsd": "/var/db/mysql",
+		},
+		family: "mariadb",
+	}
+	mySQLFlavor = &ServerFlavor{
+		Name:             FlavorMySQL,
+		ServerBinaries:   []string{"mysqld"},
+		AdminBinaries:    []string{"mysqladmin"},
+		ClientBinaries:   []string{"mysql"},
+		UpgradeBinaries:  []string{"mysql_upgrade"},
+		TimezoneBinaries: []string{"mysql_tzinfo_to_sql"},
+		DefaultDataDirs: map[string]string{
+			"windows": `C:\ProgramData\MySQL\MySQL Server 8.0\Data`,
+			"linux":   "/var/lib/mysql",
+			"darwin":  "/usr/local/mysql/data",
+			"freebsd": "/var/db/mysql",
+		},
+		FullDowngradeCheck: true,
+		family:             "mysql",
+	}
+	perconaFlavor = &ServerFlavor{
+		Name:             FlavorPercona,
+		ServerBinaries:   []string{"mysqld"},
+		AdminBinaries:    []string{"mysqladmin"},
+		ClientBinaries:   []string{"mysql"},
+		UpgradeBinaries:  []string{"mysql_upgrade"},
+		TimezoneBinaries: []string{"mysql_tzinfo_to_sql"},
+		DefaultDataDirs: map[string]string{
+			"linux":   "/var/lib/mysql",
+			"freebsd": "/var/db/mysql",
+		},
+		FullDowngradeCheck: true,
+		family:             "mysql",
+	}
+)
+
+// FlavorByName returns the flavor with a name, or MariaDB for unknown names
+func FlavorByName(name string) *ServerFlavor {
+	switch name {
+	case FlavorMySQL:
+		return mySQLFlavor
+	case FlavorPercona:
+		return perconaFlavor
+	default:
+		return mariaDBFlavor
+	}
+}
+
+// GetBinaryFlavor returns the flavor of the server in a bin directory
+func GetBinaryFlavor(binDir string) *ServerFlavor {
+	return FlavorByName(getBinaryInfo(binDir).flavor)
+}
+
+// ClientToolPath returns the command-line client matching the running server
+func ClientToolPath() string {
+	binDir := ActiveBinDir()
+	return GetBinaryFlavor(binDir).ClientPath(binDir)
+}
+
+// ToolPath returns the first of a flavor's binaries present in binDir, or the
+// last name if none is, so error messages name the traditional tool
+func (f *ServerFlavor) ToolPath(binDir string, names []string) string {
+	for _, name := range names {
+		if path := filepath.Join(binDir, GetExecutableName(name)); PathExists(path) {
+			return path
+		}
+	}
+	return filepath.Join(binDir, GetExecutableName(names[len(names)-1]))
+}
+
+// AdminPath returns the admin tool (mariadb-admin or mysqladmin) in binDir
+func (f *ServerFlavor) AdminPath(binDir string) string {
+	return f.ToolPath(binDir, f.AdminBinaries)
+}
+
+// ClientPath returns the command-line client (mariadb or mysql) in binDir
+func (f *ServerFlavor) ClientPath(binDir string) string {
+	return f.ToolPath(binDir, f.ClientBinaries)
+}
+
+// ShutdownArgs returns the admin tool arguments that stop the server. Both
+// families wait for the server to exit before returning.
+func (f *ServerFlavor) ShutdownArgs() []string {
+	return []string{"shutdown"}
+}
+
+// InitCommand returns the command that initializes an empty data directory:
+// mariadb-install-db for MariaDB and mysqld --initialize-insecure for MySQL
+// and Percona, both leaving root without a password. authMethod is MariaDB's
+// root authentication method, normal or socket.
+func (f *ServerFlavor) InitCommand(binDir, dataDir, configFile, authMethod string) (*exec.Cmd, error) {
+	for _, name := range f.InstallDBBinaries {
+		installDbPath := filepath.Join(binDir, GetExecutableName(name))
+		if !PathExists(installDbPath) {
+			continue
+		}
+		args := []string{}
+		if configFile != "" {
+			args = append(args, "--defaults-file="+configFile)
+		}
+		args = append(args, "--datadir="+dataDir, "--auth-root-authentication-method="+authMethod)
+		return exec.Command(installDbPath, args...), nil
+	}
+
+	mysqldPath := f.ToolPath(binDir, f.ServerBinaries)
+	if !PathExists(mysqldPath) {
+		return nil, fmt.Errorf("no %s server binary found in %s", f.Name, binDir)
+	}
+	args := []string{}
+	if configFile != "" {
+		args = append(args, "--defaults-file="+configFile)
+	}
+	args = append(args, "--initialize-insecure", "--datadir="+dataDir)
+	return exec.Command(mysqldPath, args...), nil
+}
+
+// DefaultDataDir returns the flavor's packaged data directory on this platform
+func (f *ServerFlavor) DefaultDataDir() string {
+	return f.DefaultDataDirs[runtime.GOOS]
+}
+
+// ServerVersion is a parsed server version
+type ServerVersion struct {
+	Major, Minor, Patch int
+}
+
+// ParseServerVersion parses versions such as "10.11.6-MariaDB-0+deb12u1",
+// "5.5.5-10.11.6-MariaDB" (the MariaDB replication prefix), "8.0.36" and
+// "8.0.35-27" (Percona)
+func ParseServerVersion(version string) (ServerVersion, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "5.5.5-")
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return ServerVersion{}, false
+	}
+	numbers := make([]int, 3)
+	for i := 0; i < len(parts) && i < 3; i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return ServerVersion{}, false
+		}
+		numbers[i] = n
+	}
+	return ServerVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, true
+}
+
+// Compare returns -1, 0 or 1. Patch releases are ignored unless withPatch is set.
+func (v ServerVersion) Compare(other ServerVersion, withPatch bool) int {
+	a := []int{v.Major, v.Minor, v.Patch}
+	b := []int{other.Major, other.Minor, other.Patch}
+	n := 3
+	if !withPatch {
+		n = 2
+	}
+	for i := 0; i < n; i++ {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v ServerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// DataDirServer is the server a data directory was created or last run with.
+// Version is empty when only the flavor could be inferred.
+type DataDirServer struct {
+	Flavor  string
+	Version string
+	Source  string // File the information was read from
+}
+
+func (s DataDirServer) String() string {
+	if s.Version == "" {
+		return s.Flavor
+	}
+	return s.Flavor + " " + s.Version
+}
+
+// DetectDataDirServer inspects a data directory for the flavor and version of
+// the server that wrote it. It returns false for empty or unrecognized directories.
+func DetectDataDirServer(dataDir string) (DataDirServer, bool) {
+	// Written by DBSwitcher after initializing or starting the directory
+	if lastRun, _ := readDataDirServerFile(dataDir); len(lastRun) >= 2 {
+		return DataDirServer{Flavor: FlavorByName(lastRun[0]).Name, Version: lastRun[1], Source: dataDirServerFile}, true
+	}
+
+	// Written by mariadb-upgrade/mysql_upgrade and by recent install scripts
+	for _, name := range []string{"mariadb_upgrade_info", "mysql_upgrade_info"} {
+		data, err := os.ReadFile(filepath.Join(dataDir, name))
+		version := strings.TrimSpace(strings.TrimRight(string(data), "\x00"))
+		if err != nil || version == "" {
+			continue
+		}
+		return DataDirServer{Flavor: serverFlavor(version), Version: version, Source: name}, true
+	}
+
+	// Files only one family creates
+	switch {
+	case PathExists(filepath.Join(dataDir, "aria_log_control")):
+		return DataDirServer{Flavor: FlavorMariaDB, Source: "aria_log_control"}, true
+	case PathExists(filepath.Join(dataDir, "mysql.ibd")):
+		// The MySQL 8.0 data dictionary
+		return DataDirServer{Flavor: FlavorMySQL, Version: "8.0.0", Source: "mysql.ibd"}, true
+	}
+	return DataDirServer{}, false
+}
+
+// RecordDataDirServer remembers the server that ran a data directory, so a later
+// start with another flavor or an older version is refused
+func RecordDataDirServer(dataDir, binDir string) {
+	_, upgraded := readDataDirServerFile(dataDir)
+	writeDataDirServerFile(dataDir, binDir, upgraded)
+}
+
+// readDataDirServerFile returns the fields of the last-run line and the upgraded
+// version of a data directory's server file
+func readDataDirServerFile(dataDir string) ([]string, string) {
+	data, err := os.ReadFile(filepath.Join(dataDir, dataDirServerFile))
+	if err != nil {
+		return nil, ""
+	}
+	lines := strings.Split(string(data), "\n")
+	upgraded := ""
+	for _, line := range lines[1:] {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "upgraded" {
+			upgraded = fields[1]
+		}
+	}
+	return strings.Fields(lines[0]), upgraded
+}
+
+// writeDataDirServerFile records the server in binDir as the last one to run a
+// data directory. Failures are only logged: the server may own the directory.
+func writeDataDirServerFile(dataDir, binDir, upgraded string) {
+	info := getBinaryInfo(binDir)
+	if info.flavor == "" || info.version == "Unknown" {
+		return
+	}
+	content := fmt.Sprintf("%s %s\n", info.flavor, info.version)
+	if upgraded != "" {
+		content += fmt.Sprintf("upgraded %s\n", upgraded)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, dataDirServerFile), []byte(content), 0644); err != nil {
+		AppLogger.Debug("Could not record server version in %s: %v", dataDir, err)
+	}
+}
+
+// CheckDataDirCompatibility refuses to run a data directory with a server of
+// another family (MariaDB vs MySQL/Percona) or with an older version than the
+// one that last wrote it. MariaDB can upgrade MySQL 5.x data directories.
+func CheckDataDirCompatibility(dataDir, binDir string) error {
+	existing, ok := DetectDataDirServer(dataDir)
+	if !ok {
+		return nil
+	}
+	info := getBinaryInfo(binDir)
+	if info.flavor == "" {
+		return nil
+	}
+
+	server := FlavorByName(info.flavor)
+	dataFlavor := FlavorByName(existing.Flavor)
+	dataVersion, dataVersionOK := ParseServerVersion(existing.Version)
+	serverVersion, serverVersionOK := ParseServerVersion(info.version)
+
+	if server.family != dataFlavor.family {
+		mysql5ToMariaDB := server == mariaDBFlavor && dataVersionOK && dataVersion.Major == 5
+		if !mysql5ToMariaDB {
+			return fmt.Errorf("data directory %s was created by %s and cannot be started with %s %s (from %s)",
+				dataDir, existing, server.Name, info.version, existing.Source)
+		}
+		return nil
+	}
+
+	// mariadb-upgrade may have been run by a newer server than the last one started here
+	if upgraded, ok := ParseServerVersion(DataDirUpgradedVersion(dataDir)); ok && (!dataVersionOK || upgraded.Compare(dataVersion, true) > 0) {
+		dataVersion, dataVersionOK = upgraded, true
+		existing.Version = DataDirUpgradedVersion(dataDir)
+	}
+
+	if dataVersionOK && serverVersionOK && serverVersion.Compare(dataVersion, server.FullDowngradeCheck) < 0 {
+		return fmt.Errorf("data directory %s was last used by %s %s and cannot be started with the older %s %s: "+
+			"downgrades are not supported because the newer server may have changed system tables and file formats the older one cannot read. "+
+			"Start it with %s %s or newer, or restore a backup taken before the upgrade (%s.pre-upgrade-*)",
+			dataDir, existing.Flavor, existing.Version, server.Name, info.version, existing.Flavor, dataVersion, dataDir)
+	}
+	return nil
+}
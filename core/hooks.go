@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HookEvent identifies the point in a lifecycle operation at which hooks run
+type HookEvent string
+
+// Hook events. Pre-hooks run before the operation and can veto it by exiting
+// non-zero; post-hooks run after it succeeded and only log failures.
+const (
+	HookPreStart   HookEvent = "pre-start"
+	HookPostStart  HookEvent = "post-start"
+	HookPreStop    HookEvent = "pre-stop"
+	HookPostStop   HookEvent = "post-stop"
+	HookPreSwitch  HookEvent = "pre-switch"
+	HookPostSwitch HookEvent = "post-switch"
+)
+
+// IsPre reports whether hooks for this event can veto the operation
+func (e HookEvent) IsPre() bool {
+	return strings.HasPrefix(string(e), "pre-")
+}
+
+// HookContext describes the operation a hook runs for
+type HookContext struct {
+	Event          HookEvent
+	Config         MariaDBConfig
+	PreviousConfig string // Name of the configuration being switched away from, if any
+}
+
+// RunHooks runs the global hook and then the per-config hook for an event.
+// For pre-events the first failing hook aborts and its error is returned so
+// the caller can cancel the operation. Post-event failures are only logged.
+func RunHooks(hookCtx HookContext) error {
+	commands := []struct {
+		source  string
+		command string
+	}{
+		{"global", AppConfig.Hooks[string(hookCtx.Event)]},
+		{hookCtx.Config.Name, hookCtx.Config.Options["hook-"+string(hookCtx.Event)]},
+	}
+
+	for _, c := range commands {
+		if strings.TrimSpace(c.command) == "" {
+			continue
+		}
+
+		err := runHook(hookCtx, c.command)
+		if err == nil {
+			continue
+		}
+
+		if hookCtx.Event.IsPre() {
+			AppLogger.Error("%s hook (%s) vetoed the operation: %v", hookCtx.Event, c.source, err)
+			return fmt.Errorf("%s hook (%s) failed: %v", hookCtx.Event, c.source, err)
+		}
+		AppLogger.Warn("%s hook (%s) failed: %v", hookCtx.Event, c.source, err)
+	}
+	return nil
+}
+
+// runHook executes a single hook command through the platform shell
+func runHook(hookCtx HookContext, command string) error {
+	timeout := hookTimeout(hookCtx.Config)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), hookEnvironment(hookCtx)...)
+	// Don't wait forever on background children that keep the output pipe open
+	cmd.WaitDelay = 2 * time.Second
+	if hookCtx.Config.Path != "" {
+		cmd.Dir = filepath.Dir(hookCtx.Config.Path)
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	AppLogger.Info("Running %s hook: %s", hookCtx.Event, command)
+	err := cmd.Run()
+
+	if out := strings.TrimSpace(output.String()); out != "" {
+		AppLogger.Log("%s hook output:\n%s", hookCtx.Event, out)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		if out := strings.TrimSpace(output.String()); out != "" {
+			return fmt.Errorf("%v: %s", err, lastLine(out))
+		}
+		return err
+	}
+	return nil
+}
+
+// shellCommand runs a command line through the platform shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// hookTimeout returns the per-config hook-timeout if set, otherwise the global one
+func hookTimeout(cfg MariaDBConfig) time.Duration {
+	if value, ok := cfg.Options["hook-timeout"]; ok {
+		if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		AppLogger.Warn("Ignoring invalid hook-timeout '%s' in %s", value, cfg.Name)
+	}
+	if AppConfig.HookTimeoutSecs > 0 {
+		return time.Duration(AppConfig.HookTimeoutSecs) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// hookEnvironment returns the DBSWITCHER_* variables passed to hooks
+func hookEnvironment(hookCtx HookContext) []string {
+	cfg := hookCtx.Config
+	return []string{
+		"DBSWITCHER_EVENT=" + string(hookCtx.Event),
+		"DBSWITCHER_CONFIG_NAME=" + cfg.Name,
+		"DBSWITCHER_CONFIG_FILE=" + cfg.Path,
+		"DBSWITCHER_PORT=" + cfg.Port,
+		"DBSWITCHER_DATADIR=" + ResolveDataDir(cfg),
+		"DBSWITCHER_SOCKET=" + cfg.Socket,
+		"DBSWITCHER_PREVIOUS_CONFIG=" + hookCtx.PreviousConfig,
+	}
+}
+
+// lastLine returns the last non-empty line of command output, which usually
+// holds the reason a script failed
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
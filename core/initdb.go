@@ -0,0 +1,500 @@
+package core
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InitOptions describes how an empty data directory is set up, from the keys
+// of a configuration's [dbswitcher] group:
+//
+//	init-auth-method = normal       # or socket: root logs in as the OS user (MariaDB)
+//	init-root-password = random     # generate a root password, kept in the keyring
+//	init-database = app             # create a database
+//	init-user = app                 # create a user with a generated password and
+//	                                # all privileges on init-database
+//	init-timezones = yes            # load the time zone tables
+//	init-seed-dir = seed            # run the *.sql files in this directory in name
+//	                                # order; defaults to <config name>.seed next to
+//	                                # the config file
+type InitOptions struct {
+	AuthMethod         string
+	RandomRootPassword bool
+	Database           string
+	User               string
+	Timezones          bool
+	SeedDir            string // Empty when there is nothing to seed
+}
+
+var (
+	initProgressListeners   []func(configName, line string)
+	initProgressListenersMu sync.Mutex
+)
+
+// OnInitProgress registers a function called with each step and output line
+// while a configuration's data directory is initialized
+func OnInitProgress(listener func(configName, line string)) {
+	initProgressListenersMu.Lock()
+	defer initProgressListenersMu.Unlock()
+	initProgressListeners = append(initProgressListeners, listener)
+}
+
+// reportInitProgress logs a line of initialization progress and passes it on
+func reportInitProgress(configName, line string) {
+	AppLogger.Log("[init %s] %s", configName, line)
+
+	initProgressListenersMu.Lock()
+	listeners := append([]func(string, string){}, initProgressListeners...)
+	initProgressListenersMu.Unlock()
+
+	for _, listener := range listeners {
+		listener(configName, line)
+	}
+}
+
+// InitOptionsForConfig reads a configuration's initialization options
+func InitOptionsForConfig(cfg MariaDBConfig, flavor *ServerFlavor) (InitOptions, error) {
+	opts := InitOptions{
+		AuthMethod:         strings.ToLower(cfg.Options["init-auth-method"]),
+		RandomRootPassword: strings.EqualFold(cfg.Options["init-root-password"], "random"),
+		Database:           cfg.Options["init-database"],
+		User:               cfg.Options["init-user"],
+		Timezones:          isEnabledOption(cfg.Options["init-timezones"]),
+	}
+
+	switch opts.AuthMethod {
+	case "":
+		opts.AuthMethod = "normal"
+	case "normal":
+	case "socket":
+		if flavor.InstallDBBinaries == nil {
+			return opts, fmt.Errorf("init-auth-method=socket is only supported by MariaDB")
+		}
+		if runtime.GOOS == "windows" {
+			return opts, fmt.Errorf("init-auth-method=socket is not supported on Windows")
+		}
+	default:
+		return opts, fmt.Errorf("unknown init-auth-method '%s' (use normal or socket)", opts.AuthMethod)
+	}
+	if value := cfg.Options["init-root-password"]; value != "" && !opts.RandomRootPassword {
+		return opts, fmt.Errorf("init-root-password must be 'random'; passwords are not read from config files")
+	}
+
+	configDir := filepath.Dir(cfg.Path)
+	if seedDir := cfg.Options["init-seed-dir"]; seedDir != "" {
+		if !filepath.IsAbs(seedDir) {
+			seedDir = filepath.Join(configDir, seedDir)
+		}
+		if !PathExists(seedDir) {
+			return opts, fmt.Errorf("init-seed-dir %s does not exist", seedDir)
+		}
+		opts.SeedDir = seedDir
+	} else if seedDir := filepath.Join(configDir, cfg.Name+".seed"); PathExists(seedDir) {
+		opts.SeedDir = seedDir
+	}
+	return opts, nil
+}
+
+// needsBootstrap reports whether anything is done after the initialization tool
+func (o InitOptions) needsBootstrap() bool {
+	return o.RandomRootPassword || o.Database != "" || o.User != "" || o.Timezones || o.SeedDir != ""
+}
+
+// isEnabledOption reports whether a [dbswitcher] value turns an option on
+func isEnabledOption(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "yes", "true", "on":
+		return true
+	}
+	return false
+}
+
+// InitializeConfigDataDir initializes a configuration's empty data directory
+// with the server in binDir and then applies its init-* options through a
+// temporary server that only listens locally. Progress is reported to the
+// OnInitProgress listeners.
+func InitializeConfigDataDir(cfg MariaDBConfig, binDir, dataDir, configFile string) error {
+	flavor := GetBinaryFlavor(binDir)
+	progress := func(line string) { reportInitProgress(cfg.Name, line) }
+
+	opts, err := InitOptionsForConfig(cfg, flavor)
+	if err != nil {
+		progress("Initialization failed: " + err.Error())
+		return err
+	}
+
+	progress(fmt.Sprintf("Initializing %s with %s", dataDir, flavor.Name))
+	if err := initializeDataDir(binDir, dataDir, "", opts.AuthMethod, progress); err != nil {
+		// Some servers need the config file's settings (e.g. lower_case_table_names)
+		progress("Retrying with the settings of " + configFile)
+		if err := initializeDataDir(binDir, dataDir, configFile, opts.AuthMethod, progress); err != nil {
+			progress("Initialization failed: " + err.Error())
+			return fmt.Errorf("failed to initialize data directory: %v", err)
+		}
+	}
+
+	if opts.needsBootstrap() {
+		if err := bootstrapDataDir(cfg, binDir, dataDir, configFile, opts, progress); err != nil {
+			progress("Bootstrap failed: " + err.Error())
+			return fmt.Errorf("data directory was initialized but bootstrapping it failed - empty %s to start over: %v", dataDir, err)
+		}
+	}
+
+	progress("Data directory ready")
+	return nil
+}
+
+// bootstrapDataDir starts a temporary server on a freshly initialized data
+// directory, runs the init-* steps through the command-line client and shuts it
+// down. The root password is changed last, in the session that shuts down.
+func bootstrapDataDir(cfg MariaDBConfig, binDir, dataDir, configFile string, opts InitOptions, progress func(string)) error {
+	flavor := GetBinaryFlavor(binDir)
+	server, err := startBootstrapServer(binDir, dataDir, configFile, opts, progress)
+	if err != nil {
+		return err
+	}
+	defer server.kill()
+
+	if opts.Timezones {
+		if err := server.loadTimezones(flavor, progress); err != nil {
+			return err
+		}
+	}
+
+	if opts.Database != "" || opts.User != "" {
+		var sql strings.Builder
+		if opts.Database != "" {
+			progress("Creating database " + opts.Database)
+			fmt.Fprintf(&sql, "CREATE DATABASE IF NOT EXISTS %s;\n", quoteSQLIdentifier(opts.Database))
+		}
+		password := ""
+		if opts.User != "" {
+			if password, err = generatePassword(); err != nil {
+				return err
+			}
+			progress("Creating user " + opts.User)
+			// Both hosts, so the user isn't shadowed by anonymous localhost accounts
+			for _, host := range []string{"%", "localhost"} {
+				account := quoteSQLString(opts.User) + "@" + quoteSQLString(host)
+				fmt.Fprintf(&sql, "CREATE USER %s IDENTIFIED BY %s;\n", account, quoteSQLString(password))
+				if opts.Database != "" {
+					fmt.Fprintf(&sql, "GRANT ALL PRIVILEGES ON %s.* TO %s;\n", quoteSQLIdentifier(opts.Database), account)
+				}
+			}
+		}
+		if err := server.runSQL("", strings.NewReader(sql.String()), progress); err != nil {
+			return err
+		}
+		if opts.User != "" {
+			creds := MySQLCredentials{Username: opts.User, Password: password, Host: "localhost", Port: cfg.Port}
+			if err := SaveConfigUserCredentials(cfg.Name, creds); err != nil {
+				return err
+			}
+			progress(fmt.Sprintf("Saved the password of %s to %s", opts.User, credentialStore(cfg.Name)))
+		}
+	}
+
+	if opts.SeedDir != "" {
+		if err := server.runSeedFiles(opts.SeedDir, opts.Database, progress); err != nil {
+			return err
+		}
+	}
+
+	final := ""
+	if opts.RandomRootPassword {
+		password, err := generatePassword()
+		if err != nil {
+			return err
+		}
+		// Saved before it is set, so a keyring failure can't lock root out
+		creds := MySQLCredentials{Username: "root", Password: password, Host: "localhost", Port: cfg.Port}
+		if err := SaveConfigCredentials(cfg.Name, creds); err != nil {
+			return err
+		}
+		progress("Setting a random root password, saved to " + credentialStore(cfg.Name))
+		final = rootPasswordSQL(flavor, opts.AuthMethod, password)
+	}
+
+	progress("Stopping the bootstrap server")
+	if err := server.runSQL("", strings.NewReader(final+"SHUTDOWN;\n"), progress); err != nil {
+		return err
+	}
+	return server.wait(time.Duration(AppConfig.ProcessTimeoutSecs) * time.Second)
+}
+
+// rootPasswordSQL returns the statement setting root's password. With socket
+// authentication MariaDB accepts either the OS user or the password.
+func rootPasswordSQL(flavor *ServerFlavor, authMethod, password string) string {
+	if flavor.family == "mariadb" && authMethod == "socket" {
+		return fmt.Sprintf("ALTER USER 'root'@'localhost' IDENTIFIED VIA unix_socket OR mysql_native_password USING PASSWORD(%s);\n", quoteSQLString(password))
+	}
+	return fmt.Sprintf("ALTER USER 'root'@'localhost' IDENTIFIED BY %s;\n", quoteSQLString(password))
+}
+
+// bootstrapServer is a server started on a new data directory that accepts
+// connections on a private socket (or a random loopback port on Windows)
+type bootstrapServer struct {
+	cmd       *exec.Cmd
+	done      chan error
+	output    *progressWriter
+	clientDir string
+	connArgs  []string
+}
+
+// startBootstrapServer starts the server and waits until it answers pings
+func startBootstrapServer(binDir, dataDir, configFile string, opts InitOptions, progress func(string)) (*bootstrapServer, error) {
+	flavor := GetBinaryFlavor(binDir)
+	mysqldPath, err := ResolveMysqldPath(binDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// With socket authentication the OS user running initialization is the admin
+	clientUser := "root"
+	if opts.AuthMethod == "socket" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine the current user: %v", err)
+		}
+		clientUser = current.Username
+	}
+
+	args := []string{"--defaults-file=" + configFile, "--datadir=" + dataDir}
+	server := &bootstrapServer{done: make(chan error, 1), output: &progressWriter{}, clientDir: binDir}
+	if runtime.GOOS == "windows" {
+		port, err := freeLoopbackPort()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--bind-address=127.0.0.1", "--port="+port)
+		server.connArgs = []string{"--protocol=TCP", "--host=127.0.0.1", "--port=" + port}
+	} else {
+		socket := filepath.Join(os.TempDir(), fmt.Sprintf("dbswitcher-init-%d.sock", os.Getpid()))
+		args = append(args, "--skip-networking", "--socket="+socket)
+		server.connArgs = []string{"--protocol=SOCKET", "--socket=" + socket}
+	}
+	server.connArgs = append(server.connArgs, "--user="+clientUser)
+
+	progress("Starting a bootstrap server")
+	server.cmd = exec.Command(mysqldPath, args...)
+	server.cmd.Dir = binDir
+	server.cmd.Stdout = server.output
+	server.cmd.Stderr = server.output
+	AppLogger.Log("Starting bootstrap server: %s", strings.Join(server.cmd.Args, " "))
+	if err := server.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the bootstrap server: %v", err)
+	}
+	go func() { server.done <- server.cmd.Wait() }()
+
+	adminPath := flavor.AdminPath(binDir)
+	deadline := time.Now().Add(time.Duration(AppConfig.ProcessTimeoutSecs) * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-server.done:
+			server.done <- err
+			return nil, fmt.Errorf("bootstrap server exited: %v\nOutput: %s", err, server.output.String())
+		case <-time.After(500 * time.Millisecond):
+		}
+		// ping succeeds once the server is up, even if the login is refused
+		if exec.Command(adminPath, append(server.connArgs, "ping")...).Run() == nil {
+			return server, nil
+		}
+	}
+	server.kill()
+	return nil, fmt.Errorf("bootstrap server did not start within %d seconds\nOutput: %s", AppConfig.ProcessTimeoutSecs, server.output.String())
+}
+
+// runSQL runs statements from input with the command-line client, stopping at
+// the first error
+func (s *bootstrapServer) runSQL(database string, input io.Reader, progress func(string)) error {
+	flavor := GetBinaryFlavor(s.clientDir)
+	args := append(append([]string{}, s.connArgs...), "--batch")
+	if database != "" {
+		args = append(args, "--database="+database)
+	}
+
+	output := &progressWriter{report: progress}
+	cmd := exec.Command(flavor.ClientPath(s.clientDir), args...)
+	cmd.Stdin = input
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err := cmd.Run()
+	output.flush()
+	if err != nil {
+		return fmt.Errorf("%s failed: %v", filepath.Base(cmd.Path), err)
+	}
+	return nil
+}
+
+// loadTimezones pipes the system zoneinfo database through the flavor's
+// tzinfo-to-sql tool into the mysql schema
+func (s *bootstrapServer) loadTimezones(flavor *ServerFlavor, progress func(string)) error {
+	zoneinfo := "/usr/share/zoneinfo"
+	if runtime.GOOS == "windows" || !PathExists(zoneinfo) {
+		progress("Skipping time zone tables: no zoneinfo database on this system")
+		return nil
+	}
+	toolPath := flavor.ToolPath(s.clientDir, flavor.TimezoneBinaries)
+	if !PathExists(toolPath) {
+		progress(fmt.Sprintf("Skipping time zone tables: %s not found", filepath.Base(toolPath)))
+		return nil
+	}
+
+	progress("Loading time zone tables from " + zoneinfo)
+	tzCmd := exec.Command(toolPath, zoneinfo)
+	tzCmd.Stderr = &progressWriter{report: progress}
+	pipe, err := tzCmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := tzCmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %v", filepath.Base(toolPath), err)
+	}
+	sqlErr := s.runSQL("mysql", pipe, progress)
+	if err := tzCmd.Wait(); err != nil && sqlErr == nil {
+		return fmt.Errorf("%s failed: %v", filepath.Base(toolPath), err)
+	}
+	return sqlErr
+}
+
+// runSeedFiles runs the .sql files of a seed directory in name order
+func (s *bootstrapServer) runSeedFiles(seedDir, database string, progress func(string)) error {
+	entries, err := os.ReadDir(seedDir)
+	if err != nil {
+		return fmt.Errorf("failed to read seed directory: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".sql") {
+			continue
+		}
+		file, err := os.Open(filepath.Join(seedDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		progress("Running " + entry.Name())
+		err = s.runSQL(database, file, progress)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// wait waits for the server to exit after SHUTDOWN
+func (s *bootstrapServer) wait(timeout time.Duration) error {
+	select {
+	case err := <-s.done:
+		s.done <- err
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("bootstrap server did not stop within %v", timeout)
+	}
+}
+
+// kill stops the server if it is still running
+func (s *bootstrapServer) kill() {
+	select {
+	case err := <-s.done:
+		s.done <- err
+	default:
+		s.cmd.Process.Kill()
+		s.done <- <-s.done
+	}
+}
+
+// progressWriter collects command output and reports it line by line
+type progressWriter struct {
+	mu      sync.Mutex
+	report  func(string)
+	output  bytes.Buffer
+	partial string
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.output.Write(p)
+	if w.report == nil {
+		return len(p), nil
+	}
+
+	w.partial += string(p)
+	for {
+		i := strings.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(w.partial[:i], "\r")
+		w.partial = w.partial[i+1:]
+		if strings.TrimSpace(line) != "" {
+			w.report(line)
+		}
+	}
+	return len(p), nil
+}
+
+// flush reports a last line that didn't end with a newline
+func (w *progressWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.report != nil && strings.TrimSpace(w.partial) != "" {
+		w.report(w.partial)
+	}
+	w.partial = ""
+}
+
+// String returns all output written so far
+func (w *progressWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.output.String()
+}
+
+// freeLoopbackPort returns a port nothing listens on at 127.0.0.1
+func freeLoopbackPort() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to find a free port: %v", err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+// generatePassword returns a random 24 character alphanumeric password
+func generatePassword() (string, error) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	password := make([]byte, 24)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate a password: %v", err)
+		}
+		password[i] = alphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// quoteSQLIdentifier quotes a database or table name with backticks
+func quoteSQLIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteSQLString quotes a string literal
+func quoteSQLString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
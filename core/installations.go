@@ -0,0 +1,259 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Installation is a registered server build that configurations can be pinned to
+type Installation struct {
+	Name    string `json:"name"`    // Short name used to pin configs, e.g. "10.11"
+	BinDir  string `json:"bin_dir"` // Directory containing mysqld/mariadbd and the client tools
+	Version string `json:"version"` // Server version reported by --version
+	Flavor  string `json:"flavor"`  // MariaDB, MySQL or Percona
+}
+
+// ErrInstallationNameRequired is returned when a new installation has no name
+// and its version, which would name it, can't be read
+var ErrInstallationNameRequired = errors.New("the server version could not be read - give the installation a name")
+
+// binaryInfo is the cached result of probing a bin directory's server binary
+type binaryInfo struct {
+	version string
+	flavor  string
+}
+
+var (
+	binaryInfos   = map[string]binaryInfo{}
+	binaryInfosMu sync.Mutex
+)
+
+// RegisterInstallation adds a bin directory to the installation registry, or
+// refreshes its version if it is already registered. A registered installation
+// keeps its name unless a new one is given, and configurations pinned to it
+// follow a rename. A new installation's name defaults to the major.minor
+// version; one whose version can't be read needs an explicit name. The caller
+// saves the settings.
+func RegisterInstallation(name, binDir string) (Installation, error) {
+	binDir = filepath.Clean(binDir)
+	mysqldPath, err := findServerBinary(binDir)
+	if err != nil {
+		return Installation{}, err
+	}
+
+	version, flavor := probeServerBinary(mysqldPath)
+	binaryInfosMu.Lock()
+	binaryInfos[binDir] = binaryInfo{version: version, flavor: flavor}
+	binaryInfosMu.Unlock()
+
+	registered := -1
+	for i, inst := range AppConfig.Installations {
+		if filepath.Clean(inst.BinDir) == binDir {
+			registered = i
+		}
+	}
+	if registered < 0 && name == "" {
+		name = shortVersion(version)
+		if name == "" {
+			return Installation{}, ErrInstallationNameRequired
+		}
+	}
+	for i, inst := range AppConfig.Installations {
+		if i != registered && name != "" && strings.EqualFold(inst.Name, name) {
+			return Installation{}, fmt.Errorf("an installation named '%s' is already registered (%s)", name, inst.BinDir)
+		}
+	}
+
+	if registered >= 0 {
+		inst := &AppConfig.Installations[registered]
+		inst.Version = version
+		inst.Flavor = flavor
+		if name != "" && name != inst.Name {
+			for configName, pinned := range AppConfig.ConfigInstallations {
+				if strings.EqualFold(pinned, inst.Name) {
+					AppConfig.ConfigInstallations[configName] = name
+				}
+			}
+			inst.Name = name
+		}
+		return *inst, nil
+	}
+
+	inst := Installation{Name: name, BinDir: binDir, Version: version, Flavor: flavor}
+	AppConfig.Installations = append(AppConfig.Installations, inst)
+	AppLogger.Info("Registered installation %s (%s %s) at %s", inst.Name, inst.Flavor, inst.Version, inst.BinDir)
+	return inst, nil
+}
+
+// RemoveInstallation removes an installation from the registry along with the
+// configurations pinned to it
+func RemoveInstallation(name string) {
+	for i, inst := range AppConfig.Installations {
+		if strings.EqualFold(inst.Name, name) {
+			AppConfig.Installations = append(AppConfig.Installations[:i:i], AppConfig.Installations[i+1:]...)
+			break
+		}
+	}
+	for configName, pinned := range AppConfig.ConfigInstallations {
+		if strings.EqualFold(pinned, name) {
+			delete(AppConfig.ConfigInstallations, configName)
+		}
+	}
+}
+
+// PinInstallation pins a configuration to a registered installation. An empty
+// name removes the pin. The caller saves the settings.
+func PinInstallation(configName, name string) error {
+	if name == "" {
+		delete(AppConfig.ConfigInstallations, configName)
+		return nil
+	}
+	inst := FindInstallation(name)
+	if inst == nil {
+		return fmt.Errorf("installation '%s' is not registered", name)
+	}
+	if AppConfig.ConfigInstallations == nil {
+		AppConfig.ConfigInstallations = map[string]string{}
+	}
+	AppConfig.ConfigInstallations[configName] = inst.Name
+	return nil
+}
+
+// FindInstallation returns a registered installation by name
+func FindInstallation(name string) *Installation {
+	for i := range AppConfig.Installations {
+		if strings.EqualFold(AppConfig.Installations[i].Name, name) {
+			return &AppConfig.Installations[i]
+		}
+	}
+	return nil
+}
+
+// BinDirForConfig returns the bin directory a configuration runs with. In order:
+//
+//	bin = <installation name or directory>   in [dbswitcher]
+//	the installation pinned in settings
+//	basedir = <dir>                           in [mysqld], using <dir>/bin
+//	the global MariaDB binary directory
+func BinDirForConfig(cfg MariaDBConfig) string {
+	if bin := cfg.Options["bin"]; bin != "" {
+		if inst := FindInstallation(bin); inst != nil {
+			return inst.BinDir
+		}
+		if !filepath.IsAbs(bin) && cfg.Path != "" {
+			bin = filepath.Join(filepath.Dir(cfg.Path), bin)
+		}
+		return bin
+	}
+
+	if name := AppConfig.ConfigInstallations[cfg.Name]; name != "" {
+		if inst := FindInstallation(name); inst != nil {
+			return inst.BinDir
+		}
+		AppLogger.Warn("Installation '%s' pinned for %s is not registered", name, cfg.Name)
+	}
+
+	if cfg.BaseDir != "" {
+		if binDir := filepath.Join(cfg.BaseDir, "bin"); PathExists(binDir) {
+			return binDir
+		}
+	}
+
+	return AppConfig.MariaDBBin
+}
+
+// ActiveBinDir returns the bin directory of the running configuration, so client
+// tools match the server, or the global one if nothing known is running
+func ActiveBinDir() string {
+	if CurrentStatus.IsRunning && CurrentStatus.ConfigFile != "" {
+		return BinDirForConfig(ConfigForPath(CurrentStatus.ConfigFile))
+	}
+	return AppConfig.MariaDBBin
+}
+
+// ConfigServerVersion returns the server version a configuration runs with,
+// or the image for container configurations
+func ConfigServerVersion(cfg MariaDBConfig) string {
+	if IsContainerConfig(cfg) {
+		return ContainerImage(cfg)
+	}
+
+	binDir := filepath.Clean(BinDirForConfig(cfg))
+	for _, inst := range AppConfig.Installations {
+		if filepath.Clean(inst.BinDir) == binDir && inst.Version != "" {
+			return inst.Version
+		}
+	}
+	return GetBinaryVersion(binDir)
+}
+
+// GetBinaryVersion returns the version of the server in a bin directory. Results
+// are cached for the lifetime of the process.
+func GetBinaryVersion(binDir string) string {
+	return getBinaryInfo(binDir).version
+}
+
+// getBinaryInfo probes the server in a bin directory once and caches the result
+func getBinaryInfo(binDir string) binaryInfo {
+	binaryInfosMu.Lock()
+	info, ok := binaryInfos[binDir]
+	binaryInfosMu.Unlock()
+	if ok {
+		return info
+	}
+
+	info = binaryInfo{version: "Unknown"}
+	if mysqldPath, err := findServerBinary(binDir); err == nil {
+		info.version, info.flavor = probeServerBinary(mysqldPath)
+	}
+
+	binaryInfosMu.Lock()
+	binaryInfos[binDir] = info
+	binaryInfosMu.Unlock()
+	return info
+}
+
+// findServerBinary returns mysqld or mariadbd in a bin directory
+func findServerBinary(binDir string) (string, error) {
+	if binDir == "" {
+		return "", fmt.Errorf("MariaDB binary path not configured")
+	}
+	for _, name := range []string{"mysqld", "mariadbd"} {
+		if path := filepath.Join(binDir, GetExecutableName(name)); PathExists(path) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("neither mysqld nor mariadbd found in %s", binDir)
+}
+
+// probeServerBinary runs a server binary with --version and returns its version
+// and flavor, e.g. "10.11.6-MariaDB" from "mysqld  Ver 10.11.6-MariaDB for Linux on x86_64"
+func probeServerBinary(mysqldPath string) (string, string) {
+	output, err := exec.Command(mysqldPath, "--version").Output()
+	if err != nil {
+		return "Unknown", ""
+	}
+
+	firstLine := strings.SplitN(string(output), "\n", 2)[0]
+	flavor := serverFlavor(firstLine)
+	parts := strings.Fields(firstLine)
+	for i, part := range parts {
+		if strings.Contains(part, "Ver") && i+1 < len(parts) {
+			return parts[i+1], flavor
+		}
+	}
+	return "Unknown", flavor
+}
+
+// shortVersion returns the major.minor part of a version, e.g. "10.11"
+func shortVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
@@ -1,7 +1,6 @@
 package core
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,7 +8,6 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 )
 
@@ -26,6 +24,19 @@ func GetMariaDBStatus() MariaDBStatus {
 		return status
 	}
 
+	// A container started by DBSwitcher is reported like a native instance. Its
+	// server may also show up in the host's process list, without --defaults-file.
+	if cfg, state, found := FindRunningContainer(); found {
+		status.ProcessID = state.Pid
+		status.ConfigFile = cfg.Path
+		status.ConfigName = cfg.Name
+		status.Port = cfg.Port
+		status.DataPath = cfg.DataDir
+		status.ServiceName = "container " + state.Name
+		status.Version = ConfigServerVersion(cfg)
+		return status
+	}
+
 	// Get process details
 	processName := AppConfig.ProcessNames[runtime.GOOS]
 	if processName == "" {
@@ -77,20 +88,30 @@ func GetMariaDBStatus() MariaDBStatus {
 		}
 	}
 
-	// Try to get version
-	status.Version = GetMariaDBVersion()
+	// Report the systemd unit running the server, if any
+	status.ServiceName = findActiveServiceUnit(status.ConfigFile)
+
+	// Report the version of the installation the configuration runs with
+	if status.ConfigFile != "" {
+		status.Version = ConfigServerVersion(ConfigForPath(status.ConfigFile))
+	} else {
+		status.Version = GetMariaDBVersion()
+	}
 
 	return status
 }
 
-// IsMariaDBRunning checks if MariaDB/MySQL is running
+// IsMariaDBRunning checks if MariaDB/MySQL is running natively or in a container
 func IsMariaDBRunning() bool {
 	processName := AppConfig.ProcessNames[runtime.GOOS]
 	if processName == "" {
 		processName = "mysqld"
 	}
 	
-	_, _, found := FindProcessWithCmdLine(processName)
+	if _, _, found := FindProcessWithCmdLine(processName); found {
+		return true
+	}
+	_, _, found := FindRunningContainer()
 	return found
 }
 
@@ -266,10 +287,7 @@ func queryDatabasePort() string {
 	// Try to connect with default credentials and query the port
 	creds := GetDefaultCredentials()
 	
-	mysqlPath := filepath.Join(AppConfig.MariaDBBin, "mysql")
-	if runtime.GOOS == "windows" {
-		mysqlPath += ".exe"
-	}
+	mysqlPath := ClientToolPath()
 
 	// Build command to query the port
 	args := []string{
@@ -372,104 +390,104 @@ func getPortFromNetstat() string {
 	return ""
 }
 
-// GetMariaDBVersion returns the MariaDB version
+// GetMariaDBVersion returns the version of the MariaDB server in the global bin directory
 func GetMariaDBVersion() string {
-	mysqldPath := filepath.Join(AppConfig.MariaDBBin, "mysqld")
-	if runtime.GOOS == "windows" {
-		mysqldPath += ".exe"
-	}
+	return GetBinaryVersion(AppConfig.MariaDBBin)
+}
 
-	cmd := exec.Command(mysqldPath, "--version")
-	output, err := cmd.Output()
-	if err != nil {
-		return "Unknown"
+// GetDefaultDataDir returns the packaged data directory of the server flavor in
+// the global bin directory
+func GetDefaultDataDir() string {
+	if runtime.GOOS == "windows" && AppConfig.MariaDBBin != "" {
+		return filepath.Join(filepath.Dir(AppConfig.MariaDBBin), "data")
 	}
+	return GetBinaryFlavor(AppConfig.MariaDBBin).DefaultDataDir()
+}
 
-	lines := strings.Split(string(output), "\n")
-	if len(lines) > 0 {
-		parts := strings.Fields(lines[0])
-		for i, part := range parts {
-			if strings.Contains(part, "Ver") && i+1 < len(parts) {
-				return parts[i+1]
-			}
-		}
+// StartOptions adjusts how StartMariaDBWithOptions starts a server
+type StartOptions struct {
+	// Ephemeral starts a throwaway instance next to whatever else is running.
+	// Everything that tracks the running instance is skipped: the distro
+	// service, the single-instance check, systemd units, supervision, the last
+	// used configuration, notifications and hooks. The server stays a child of
+	// this process, and OnProcess receives it.
+	Ephemeral bool
+	OnProcess func(*os.Process)
+	// Cancel aborts the start when it is closed, e.g. on Ctrl+C. A server that
+	// was already launched is left to the caller.
+	Cancel <-chan struct{}
+}
+
+// errStartCancelled is returned when StartOptions.Cancel aborts a start
+var errStartCancelled = fmt.Errorf("start cancelled")
+
+// isCancelled reports whether cancel is closed
+func isCancelled(cancel <-chan struct{}) bool {
+	select {
+	case <-cancel:
+		return true
+	default:
+		return false
 	}
-	return "Unknown"
 }
 
-// GetDefaultDataDir returns the default data directory for MariaDB
-func GetDefaultDataDir() string {
-	switch runtime.GOOS {
-	case "windows":
-		if AppConfig.MariaDBBin != "" {
-			return filepath.Join(filepath.Dir(AppConfig.MariaDBBin), "data")
-		}
-		return `C:\Program Files\MariaDB\data`
-	case "linux":
-		return "/var/lib/mysql"
-	case "darwin":
-		return "/usr/local/var/mysql"
-	case "freebsd":
-		return "/var/db/mysql"
+// sleepUnlessCancelled waits for d and reports false if cancel is closed first
+func sleepUnlessCancelled(d time.Duration, cancel <-chan struct{}) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-cancel:
+		return false
 	}
-	return ""
 }
 
 // StartMariaDBWithConfig starts MariaDB with the specified configuration file
 func StartMariaDBWithConfig(configFile string) error {
-	AppLogger.Log("========================================")
-	AppLogger.Log("STARTING MARIADB")
-	AppLogger.Log("========================================")
-	
-	// Check if MariaDB is already running
-	if IsMariaDBRunning() {
-		AppLogger.Log("MariaDB is already running")
-		return fmt.Errorf("MariaDB is already running - please stop it first")
+	return StartMariaDBWithOptions(configFile, StartOptions{})
+}
+
+// SwitchToConfig makes a configuration the running one and runs the switch
+// hooks around it. Pre-switch hooks can veto the switch. A running server is
+// stopped with stop first; without stop, starting fails while one is running.
+func SwitchToConfig(cfg MariaDBConfig, stop func() error) error {
+	previousConfig := ""
+	running := IsMariaDBRunning()
+	if running {
+		previousConfig = GetMariaDBStatus().ConfigName
+	}
+	hookCtx := HookContext{Event: HookPreSwitch, Config: cfg, PreviousConfig: previousConfig}
+	if err := RunHooks(hookCtx); err != nil {
+		return fmt.Errorf("switch cancelled: %v", err)
 	}
 
-	// Validate config.MariaDBBin
-	if AppConfig.MariaDBBin == "" {
-		AppLogger.Error(" MariaDB binary path is empty!")
-		return fmt.Errorf("MariaDB binary path not configured")
+	if running && stop != nil {
+		AppLogger.Log("Stopping %s before switching to %s", previousConfig, cfg.Name)
+		if err := stop(); err != nil {
+			return fmt.Errorf("failed to stop current MariaDB instance: %v", err)
+		}
 	}
 
-	// Check if binary directory exists
-	if !PathExists(AppConfig.MariaDBBin) {
-		AppLogger.Error(" MariaDB binary directory does not exist: %s", AppConfig.MariaDBBin)
-		return fmt.Errorf("MariaDB binary directory not found: %s", AppConfig.MariaDBBin)
+	if err := StartMariaDBWithConfig(cfg.Path); err != nil {
+		NotifySwitchFailed(cfg.Name, err)
+		return err
 	}
 
-	// Build full mysqld path
-	mysqldPath := filepath.Join(AppConfig.MariaDBBin, GetExecutableName("mysqld"))
-	AppLogger.Log("Full mysqld path: %s", mysqldPath)
+	hookCtx.Event = HookPostSwitch
+	RunHooks(hookCtx)
+	return nil
+}
+
+// StartMariaDBWithOptions starts MariaDB with the specified configuration file
+// and options
+func StartMariaDBWithOptions(configFile string, opts StartOptions) error {
+	AppLogger.Log("========================================")
+	AppLogger.Log("STARTING MARIADB")
+	AppLogger.Log("========================================")
 	
-	// Check if mysqld exists
-	if !PathExists(mysqldPath) {
-		AppLogger.Error(" mysqld not found at: %s", mysqldPath)
-		
-		// Try mariadbd as alternative
-		mariadbdPath := filepath.Join(AppConfig.MariaDBBin, GetExecutableName("mariadbd"))
-		if PathExists(mariadbdPath) {
-			AppLogger.Log("Found mariadbd instead of mysqld at: %s", mariadbdPath)
-			mysqldPath = mariadbdPath
-		} else {
-			// Try to find mysqld using which/where
-			var findCmd *exec.Cmd
-			if runtime.GOOS == "windows" {
-				findCmd = exec.Command("where", "mysqld.exe")
-			} else {
-				findCmd = exec.Command("which", "mysqld")
-			}
-			
-			if output, err := findCmd.Output(); err == nil {
-				foundPath := strings.TrimSpace(string(output))
-				AppLogger.Log("Found mysqld at: %s", foundPath)
-				mysqldPath = foundPath
-			} else {
-				AppLogger.Log("Could not find mysqld in system PATH")
-				return fmt.Errorf("mysqld not found at: %s", mysqldPath)
-			}
-		}
+	// Check if MariaDB is already running (the distro service may be stopped below)
+	if !opts.Ephemeral && IsMariaDBRunning() && !(ShouldStopDistroService() && IsDistroServiceActive()) {
+		AppLogger.Log("MariaDB is already running")
+		return fmt.Errorf("MariaDB is already running - please stop it first")
 	}
 
 	// Check if config file exists
@@ -491,8 +509,49 @@ func StartMariaDBWithConfig(configFile string) error {
 	configData := ParseConfigFile(configFile)
 	AppLogger.Log("Config parsed - DataDir: %s, Port: %s", configData.DataDir, configData.Port)
 	
-	// Validate and prepare data directory
-	if configData.DataDir != "" {
+	// Fail early with a clear message if the server cannot run: privileged ports,
+	// its OS user and the ownership of the data directory
+	hookConfig := ConfigForPath(absConfigFile)
+	container := IsContainerConfig(hookConfig)
+	if container && opts.Ephemeral {
+		return fmt.Errorf("container configurations can't run as ephemeral instances")
+	}
+	warnings, err := PreflightCheck(hookConfig)
+	for _, warning := range warnings {
+		AppLogger.Warn(" %s", warning)
+	}
+	if err != nil {
+		AppLogger.Error(" Pre-flight check failed: %v", err)
+		return err
+	}
+	
+	// Run pre-start hooks, which may veto the start. They belong to the
+	// configuration's own instance, not to throwaway copies of it.
+	if !opts.Ephemeral {
+		if err := RunHooks(HookContext{Event: HookPreStart, Config: hookConfig}); err != nil {
+			return err
+		}
+	}
+	
+	// Locate the server binary of the configuration's installation; containers bring their own
+	binDir := BinDirForConfig(hookConfig)
+	mysqldPath := ""
+	if !container {
+		if mysqldPath, err = ResolveMysqldPath(binDir); err != nil {
+			return err
+		}
+	}
+	
+	// Stop the distribution's MariaDB service so it doesn't hold the port
+	if !opts.Ephemeral && ShouldStopDistroService() && IsDistroServiceActive() {
+		AppLogger.Info("Stopping distro service %s before starting %s", GetDistroServiceName(), hookConfig.Name)
+		if err := StopLinuxService(); err != nil {
+			return fmt.Errorf("failed to stop %s service: %v", GetDistroServiceName(), err)
+		}
+	}
+	
+	// Validate and prepare data directory (the container image initializes its own)
+	if configData.DataDir != "" && !container {
 		// Convert to absolute path if relative
 		if !filepath.IsAbs(configData.DataDir) {
 			configData.DataDir = filepath.Join(filepath.Dir(absConfigFile), configData.DataDir)
@@ -510,12 +569,12 @@ func StartMariaDBWithConfig(configFile string) error {
 		// Check if data directory is empty and needs initialization
 		if isEmpty, _ := IsDirEmpty(configData.DataDir); isEmpty {
 			AppLogger.Log("Data directory is empty, needs initialization")
-			if err := InitializeDataDir(configData.DataDir); err != nil {
+			if err := InitializeConfigDataDir(hookConfig, binDir, configData.DataDir, absConfigFile); err != nil {
 				AppLogger.Error(" Failed to initialize data directory: %v", err)
-				// Try alternative initialization
-				if err := InitializeDataDirAlternative(configData.DataDir, absConfigFile); err != nil {
-					return fmt.Errorf("failed to initialize data directory: %v", err)
-				}
+				return err
+			}
+			if isCancelled(opts.Cancel) {
+				return errStartCancelled
 			}
 		} else {
 			// Check for critical files in data directory
@@ -525,12 +584,23 @@ func StartMariaDBWithConfig(configFile string) error {
 		}
 	}
 
+	// Refuse data directories written by another server family or a newer version
+	if !container {
+		if err := CheckDataDirCompatibility(ResolveDataDir(hookConfig), binDir); err != nil {
+			AppLogger.Error(" %v", err)
+			return err
+		}
+	}
+
 	// Check if MySQL/MariaDB is still running - no force stop
 	AppLogger.Log("Checking if all MySQL/MariaDB processes are stopped...")
-	if IsMariaDBRunning() {
+	if !opts.Ephemeral && IsMariaDBRunning() {
 		return fmt.Errorf("MySQL/MariaDB is still running - please stop it gracefully with credentials before starting a new instance")
 	}
 	
+	// Take the port back from DBSwitcher if it waits there for an on-demand configuration
+	releaseOnDemandPort(configData.Port)
+
 	// Double-check the port is free
 	if !IsPortAvailable(configData.Port) {
 		AppLogger.Log("Port %s is still in use", configData.Port)
@@ -540,10 +610,12 @@ func StartMariaDBWithConfig(configFile string) error {
 	AppLogger.Log("Port %s is confirmed available", configData.Port)
 
 	// First, try to validate the config file syntax
-	AppLogger.Log("Validating configuration file syntax...")
-	if err := ValidateConfigFile(mysqldPath, absConfigFile); err != nil {
-		AppLogger.Warn(" Config file validation failed: %v", err)
-		// Continue anyway, as some versions don't support --validate-config
+	if !container {
+		AppLogger.Log("Validating configuration file syntax...")
+		if err := ValidateConfigFile(mysqldPath, absConfigFile); err != nil {
+			AppLogger.Warn(" Config file validation failed: %v", err)
+			// Continue anyway, as some versions don't support --validate-config
+		}
 	}
 
 	// Start the MariaDB process with better error capture
@@ -557,41 +629,48 @@ func StartMariaDBWithConfig(configFile string) error {
 	
 	cmd := exec.Command(mysqldPath, args...)
 	
-	// Capture both stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Capture both stdout and stderr in a console log that outlives this process,
+	// so startup failures and later crashes can be diagnosed
+	consoleLogPath := GetConsoleLogPath(hookConfig.Name)
+	consoleLog, err := os.Create(consoleLogPath)
+	if err != nil {
+		return fmt.Errorf("failed to create console log: %v", err)
+	}
+	defer consoleLog.Close()
+	cmd.Stdout = consoleLog
+	cmd.Stderr = consoleLog
 	
 	// Set working directory to bin directory
-	cmd.Dir = AppConfig.MariaDBBin
+	cmd.Dir = binDir
 	
 	// Platform-specific configuration
-	if runtime.GOOS == "windows" {
-		// Use CREATE_NEW_PROCESS_GROUP to detach process from parent
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			HideWindow:    true, // Hide console window
-			CreationFlags: 0x00000200, // CREATE_NEW_PROCESS_GROUP - allows process to survive parent termination
-		}
-	}
+	configureDetachedProcess(cmd)
 	
 	AppLogger.Log("Executing command: %s %s", mysqldPath, strings.Join(args, " "))
 	
-	// Start the process
-	err = cmd.Start()
-	if err != nil {
-		AppLogger.Error(" Failed to start process: %v", err)
-		return fmt.Errorf("failed to start MariaDB: %v", err)
-	}
-	
-	AppLogger.Log("Process started with PID: %d", cmd.Process.Pid)
-	
-	// Release the process so it's detached from parent and can survive parent termination
-	err = cmd.Process.Release()
-	if err != nil {
-		AppLogger.Warn(" Could not release process: %v", err)
-		// Continue anyway - the process group flag should still work
-	} else {
-		AppLogger.Log("Process successfully detached from parent")
+	// Start the server in a container, as a systemd service or detached from this process
+	startedAt := time.Now()
+	var exited <-chan struct{}
+	if container {
+		if err := StartContainer(hookConfig); err != nil {
+			AppLogger.Error(" Failed to start container: %v", err)
+			return fmt.Errorf("failed to start MariaDB: %v", err)
+		}
+	} else if opts.Ephemeral {
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("failed to start MariaDB: %v", err)
+		}
+		AppLogger.Log("Ephemeral instance started with PID: %d", cmd.Process.Pid)
+		if opts.OnProcess != nil {
+			opts.OnProcess(cmd.Process)
+		}
+	} else if UseSystemdServices() {
+		if err := StartConfigService(hookConfig, mysqldPath); err != nil {
+			AppLogger.Error(" Failed to start service: %v", err)
+			return fmt.Errorf("failed to start MariaDB: %v", err)
+		}
+	} else if exited, err = startDetachedProcess(cmd); err != nil {
+		return err
 	}
 	
 	// Brief wait to allow process to initialize before verification
@@ -600,7 +679,9 @@ func StartMariaDBWithConfig(configFile string) error {
 		initWaitTime = AppConfig.ProcessTimeoutSecs / 10 // Use 10% of process timeout for init wait
 	}
 	AppLogger.Info("Waiting %d seconds for MariaDB to initialize...", initWaitTime)
-	time.Sleep(time.Duration(initWaitTime) * time.Second)
+	if !sleepUnlessCancelled(time.Duration(initWaitTime)*time.Second, opts.Cancel) {
+		return errStartCancelled
+	}
 	
 	// Additional verification - try to connect
 	AppLogger.Info("Verifying MariaDB is accessible...")
@@ -608,46 +689,139 @@ func StartMariaDBWithConfig(configFile string) error {
 	if maxRetries <= 0 {
 		maxRetries = 3 // fallback
 	}
+	// Other servers may be running next to an ephemeral instance, so only its port tells
+	isUp := IsMariaDBRunning
+	if opts.Ephemeral {
+		isUp = func() bool { return IsPortListening(configData.Port) }
+	}
 	for i := 0; i < maxRetries; i++ {
-		if IsMariaDBRunning() && IsPortListening(configData.Port) {
+		if isUp() && IsPortListening(configData.Port) {
 			AppLogger.Log("MariaDB is running and accepting connections")
 			break
 		}
 		AppLogger.Log("Waiting for MariaDB to be ready... (%d/%d)", i+1, maxRetries)
-		time.Sleep(1 * time.Second)
+		if !sleepUnlessCancelled(time.Second, opts.Cancel) {
+			return errStartCancelled
+		}
 	}
 	
 	// Final verification
-	if !IsMariaDBRunning() {
-		stdoutStr := stdout.String()
-		stderrStr := stderr.String()
+	if !isUp() {
 		AppLogger.Error(" MariaDB process not found after startup")
-		if stdoutStr != "" {
-			AppLogger.Log("Final stdout: %s", stdoutStr)
-		}
-		if stderrStr != "" {
-			AppLogger.Log("Final stderr: %s", stderrStr)
+		logPath := ErrorLogPath(hookConfig)
+		if tail := TailFile(logPath, 20); tail != "" {
+			AppLogger.Log("Last lines of %s:\n%s", logPath, tail)
+			return fmt.Errorf("MariaDB failed to start: %s", ParseMariaDBError(tail))
 		}
 		return fmt.Errorf("MariaDB failed to start - process not found. Check logs for details")
 	}
 
+	if opts.Ephemeral {
+		RecordDataDirServer(ResolveDataDir(hookConfig), binDir)
+		AppLogger.Info("Ephemeral instance %s started on port %s", hookConfig.Name, configData.Port)
+		return nil
+	}
+
 	// Save the last used config
 	AppConfig.LastUsedConfig = absConfigFile
 	SaveConfig()
+	if !container {
+		RecordDataDirServer(ResolveDataDir(hookConfig), binDir)
+	}
 	
 	// Update global status
 	CurrentStatus = GetMariaDBStatus()
+	UpdateProxyBackend(CurrentStatus)
 	
 	AppLogger.Info("========================================")
 	AppLogger.Info("MARIADB STARTED SUCCESSFULLY")
 	AppLogger.Info("========================================")
 	
 	// Show success notification
+	configName := "Unknown"
 	if config := FindConfigByPath(absConfigFile); config != nil {
-		NotifyMariaDBStarted(config.Name)
-	} else {
-		NotifyMariaDBStarted("Unknown")
+		configName = config.Name
 	}
+	RecordInstanceStart(configName, time.Since(startedAt))
+	superviseInstance(hookConfig, exited)
+	NotifyMariaDBStarted(configName)
+	
+	RunHooks(HookContext{Event: HookPostStart, Config: hookConfig})
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// startDetachedProcess starts mysqld so it survives this process. The returned
+// channel is closed when it exits.
+func startDetachedProcess(cmd *exec.Cmd) (<-chan struct{}, error) {
+	err := cmd.Start()
+	if err != nil {
+		AppLogger.Error(" Failed to start process: %v", err)
+		return nil, fmt.Errorf("failed to start MariaDB: %v", err)
+	}
+	
+	AppLogger.Log("Process started with PID: %d", cmd.Process.Pid)
+	
+	// The process group flag keeps the server running when this process exits.
+	// Waiting reaps it when it exits first, so the supervisor can tell.
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	return exited, nil
+}
+
+// ResolveMysqldPath returns the server binary in a MariaDB bin directory, falling
+// back to mariadbd and, for the global bin directory only, to the system PATH
+func ResolveMysqldPath(binDir string) (string, error) {
+	// Validate the bin directory
+	if binDir == "" {
+		AppLogger.Error(" MariaDB binary path is empty!")
+		return "", fmt.Errorf("MariaDB binary path not configured")
+	}
+
+	// Check if binary directory exists
+	if !PathExists(binDir) {
+		AppLogger.Error(" MariaDB binary directory does not exist: %s", binDir)
+		return "", fmt.Errorf("MariaDB binary directory not found: %s", binDir)
+	}
+
+	// Build full mysqld path
+	mysqldPath := filepath.Join(binDir, GetExecutableName("mysqld"))
+	AppLogger.Log("Full mysqld path: %s", mysqldPath)
+	
+	// Check if mysqld exists
+	if !PathExists(mysqldPath) {
+		AppLogger.Error(" mysqld not found at: %s", mysqldPath)
+		
+		// Try mariadbd as alternative
+		mariadbdPath := filepath.Join(binDir, GetExecutableName("mariadbd"))
+		if PathExists(mariadbdPath) {
+			AppLogger.Log("Found mariadbd instead of mysqld at: %s", mariadbdPath)
+			mysqldPath = mariadbdPath
+		} else if filepath.Clean(binDir) != filepath.Clean(AppConfig.MariaDBBin) {
+			// A pinned installation must not silently run another version from PATH
+			return "", fmt.Errorf("neither mysqld nor mariadbd found in %s", binDir)
+		} else {
+			// Try to find mysqld using which/where
+			var findCmd *exec.Cmd
+			if runtime.GOOS == "windows" {
+				findCmd = exec.Command("where", "mysqld.exe")
+			} else {
+				findCmd = exec.Command("which", "mysqld")
+			}
+			
+			if output, err := findCmd.Output(); err == nil {
+				foundPath := strings.TrimSpace(string(output))
+				AppLogger.Log("Found mysqld at: %s", foundPath)
+				mysqldPath = foundPath
+			} else {
+				AppLogger.Log("Could not find mysqld in system PATH")
+				return "", fmt.Errorf("mysqld not found at: %s", mysqldPath)
+			}
+		}
+	}
+	
+	return mysqldPath, nil
+}
@@ -12,40 +12,77 @@ import (
 
 // StopMySQLWithCredentials gracefully stops MySQL using admin credentials
 func StopMySQLWithCredentials(creds MySQLCredentials) error {
-	mysqladminPath := filepath.Join(AppConfig.MariaDBBin, "mysqladmin")
-	if runtime.GOOS == "windows" {
-		mysqladminPath += ".exe"
-	}
-	
-	if !PathExists(mysqladminPath) {
+	// Remember which configuration was running before it goes away. The CLI
+	// doesn't poll, so read the status if it isn't known yet.
+	status := CurrentStatus
+	if status.ConfigFile == "" {
+		status = GetMariaDBStatus()
+	}
+	configName := status.ConfigName
+	hookConfig := MariaDBConfig{Name: configName, Port: creds.Port}
+	if status.ConfigFile != "" {
+		hookConfig = ConfigForPath(status.ConfigFile)
+	}
+	
+	// Use the admin tool of the installation the configuration runs with
+	binDir := BinDirForConfig(hookConfig)
+	flavor := GetBinaryFlavor(binDir)
+	mysqladminPath := flavor.AdminPath(binDir)
+	
+	// Containers are stopped through the container CLI, everything else needs mysqladmin
+	container := IsContainerConfig(hookConfig)
+	if !container && !PathExists(mysqladminPath) {
 		return fmt.Errorf("mysqladmin not found at %s", mysqladminPath)
 	}
 	
-	// Build shutdown command
-	args := []string{
-		"-h", creds.Host,
-		"-P", creds.Port,
-		"-u", creds.Username,
+	// Run pre-stop hooks, which may veto the stop
+	if err := RunHooks(HookContext{Event: HookPreStop, Config: hookConfig}); err != nil {
+		return err
 	}
 	
-	// Add password if provided
-	if creds.Password != "" {
-		args = append(args, fmt.Sprintf("-p%s", creds.Password))
+	// Let connections through the proxy finish before the server goes away
+	DrainProxy(time.Duration(AppConfig.ProxyDrainSecs) * time.Second)
+	stopped := false
+	defer func() { endProxyDrain(stopped) }()
+	
+	// Tell the supervisor this exit is expected
+	expectInstanceStop(true)
+	
+	// Services started by DBSwitcher are stopped through systemd so its state stays consistent
+	var output []byte
+	var err error
+	if container {
+		err = StopContainer(hookConfig)
+	} else if unit := status.ServiceName; UseSystemdServices() && strings.HasPrefix(unit, "dbswitcher-") {
+		err = StopService(unit)
+	} else {
+		AppLogger.Log("Executing graceful shutdown with mysqladmin...")
+		// The credentials go into an option file, so the password shows up
+		// neither in the process list nor in the log
+		var optionFile string
+		optionFile, err = writeOptionFile(ConnectionDetails{
+			Host:     creds.Host,
+			Port:     creds.Port,
+			User:     creds.Username,
+			Password: creds.Password,
+		})
+		if err == nil {
+			defer removeOptionFile(optionFile)
+			// --defaults-extra-file must come first
+			args := append([]string{"--defaults-extra-file=" + optionFile}, flavor.ShutdownArgs()...)
+			AppLogger.Log("Command: %s %s", mysqladminPath, strings.Join(args, " "))
+			cmd := exec.Command(mysqladminPath, args...)
+			output, err = cmd.CombinedOutput()
+		}
 	}
 	
-	args = append(args, "shutdown")
-	
-	// Log the command for debugging
-	AppLogger.Log("Executing graceful shutdown with mysqladmin...")
-	AppLogger.Log("Command: %s %s", mysqladminPath, strings.Join(args, " "))
-	
-	cmd := exec.Command(mysqladminPath, args...)
-	output, err := cmd.CombinedOutput()
-	
 	if err != nil {
+		expectInstanceStop(false)
 		AppLogger.Log("mysqladmin shutdown error: %v\nOutput: %s", err, string(output))
 		return fmt.Errorf("shutdown failed: %v", err)
 	}
+	RecordInstanceStop(configName)
+	stopped = true
 	
 	// Wait for shutdown to complete
 	time.Sleep(3 * time.Second)
@@ -53,7 +90,9 @@ func StopMySQLWithCredentials(creds MySQLCredentials) error {
 	AppLogger.Info("MySQL shutdown command executed successfully")
 	
 	// Show notification
-	NotifyMariaDBStopped()
+	NotifyMariaDBStopped(configName)
+	
+	RunHooks(HookContext{Event: HookPostStop, Config: hookConfig})
 	
 	return nil
 }
@@ -87,58 +126,26 @@ func ValidateDataDirectory(dataDir string) bool {
 	return true
 }
 
-// InitializeDataDir initializes a new MariaDB data directory
-func InitializeDataDir(dataDir string) error {
-	// Try mysql_install_db first
-	installDbPath := filepath.Join(AppConfig.MariaDBBin, "mysql_install_db")
-	if runtime.GOOS == "windows" {
-		installDbPath += ".exe"
-	}
-	
-	if PathExists(installDbPath) {
-		cmd := exec.Command(installDbPath, "--datadir="+dataDir, "--auth-root-authentication-method=normal")
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			AppLogger.Log("mysql_install_db failed: %v\nOutput: %s", err, string(output))
-			return err
-		}
-		AppLogger.Log("Data directory initialized with mysql_install_db")
-		return nil
-	}
-	
-	// Try mysqld --initialize-insecure
-	mysqldPath := filepath.Join(AppConfig.MariaDBBin, "mysqld")
-	if runtime.GOOS == "windows" {
-		mysqldPath += ".exe"
-	}
-	
-	cmd := exec.Command(mysqldPath, "--initialize-insecure", "--datadir="+dataDir)
-	output, err := cmd.CombinedOutput()
+// initializeDataDir runs the flavor's initialization command, passing each line
+// of its output to progress if it isn't nil
+func initializeDataDir(binDir, dataDir, configFile, authMethod string, progress func(string)) error {
+	flavor := GetBinaryFlavor(binDir)
+	cmd, err := flavor.InitCommand(binDir, dataDir, configFile, authMethod)
 	if err != nil {
-		AppLogger.Log("mysqld --initialize-insecure failed: %v\nOutput: %s", err, string(output))
 		return err
 	}
-	
-	AppLogger.Log("Data directory initialized with mysqld --initialize-insecure")
-	return nil
-}
 
-// InitializeDataDirAlternative tries alternative methods to initialize data directory
-func InitializeDataDirAlternative(dataDir, configFile string) error {
-	mysqldPath := filepath.Join(AppConfig.MariaDBBin, "mysqld")
-	if runtime.GOOS == "windows" {
-		mysqldPath += ".exe"
-	}
-	
-	// Try with config file
-	cmd := exec.Command(mysqldPath, "--defaults-file="+configFile, "--initialize-insecure")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		AppLogger.Log("Alternative initialization failed: %v\nOutput: %s", err, string(output))
-		return fmt.Errorf("failed to initialize data directory: %v", err)
+	AppLogger.Log("Initializing %s data directory: %s", flavor.Name, strings.Join(cmd.Args, " "))
+	output := &progressWriter{report: progress}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		AppLogger.Log("%s failed: %v\nOutput: %s", filepath.Base(cmd.Path), err, output.String())
+		return err
 	}
-	
-	AppLogger.Log("Data directory initialized with alternative method")
+
+	AppLogger.Log("Data directory initialized with %s", filepath.Base(cmd.Path))
+	RecordDataDirUpgrade(dataDir, binDir)
 	return nil
 }
 
@@ -152,8 +159,15 @@ func ParseMariaDBError(errorOutput string) string {
 	if strings.Contains(lowerOutput, "port") && strings.Contains(lowerOutput, "already in use") {
 		return "Port already in use - another instance might be running"
 	}
-	if strings.Contains(lowerOutput, "permission denied") {
-		return "Permission denied - may need administrator privileges"
+	if strings.Contains(lowerOutput, "run mysqld as root") {
+		return "mysqld refuses to run as root - set user= in the [mysqld] section"
+	}
+	if strings.Contains(lowerOutput, "fail to change uid") || strings.Contains(lowerOutput, "can't change to run as user") {
+		return "Cannot switch to the configured user= - the server must be started as root for that"
+	}
+	if strings.Contains(lowerOutput, "permission denied") || strings.Contains(lowerOutput, "errno: 13") ||
+		strings.Contains(lowerOutput, "errcode: 13") || strings.Contains(lowerOutput, "error number 13") {
+		return "Permission denied - the data directory or its files are not accessible to the server's OS user; use 'dbswitcher fix-perms <config>' to fix the ownership"
 	}
 	if strings.Contains(lowerOutput, "data directory") && strings.Contains(lowerOutput, "not empty") {
 		return "Data directory is not empty - initialization might have failed"
@@ -168,8 +182,15 @@ func ParseMariaDBError(errorOutput string) string {
 		return "Required plugin not loaded - check configuration"
 	}
 	
-	// Return first non-empty line as fallback
+	// Prefer the server's own error message
 	lines := strings.Split(errorOutput, "\n")
+	for _, line := range lines {
+		if idx := strings.Index(line, "[ERROR]"); idx >= 0 {
+			return strings.TrimSpace(line[idx+len("[ERROR]"):])
+		}
+	}
+	
+	// Return first non-empty line as fallback
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line != "" && !strings.HasPrefix(line, "[") {
@@ -182,10 +203,7 @@ func ParseMariaDBError(errorOutput string) string {
 
 // ExecMySQLQueryWithCredentials executes a MySQL query with provided credentials
 func ExecMySQLQueryWithCredentials(variable string, creds MySQLCredentials) string {
-	mysqlPath := filepath.Join(AppConfig.MariaDBBin, "mysql")
-	if runtime.GOOS == "windows" {
-		mysqlPath += ".exe"
-	}
+	mysqlPath := ClientToolPath()
 	
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(AppConfig.ConnectionTimeoutSecs)*time.Second)
 	defer cancel()
@@ -218,6 +236,51 @@ func ExecMySQLQueryWithCredentials(variable string, creds MySQLCredentials) stri
 	return result
 }
 
+// ExecMySQLQueryRows executes a query with provided credentials and returns the
+// result rows as tab-separated columns (without the header row)
+func ExecMySQLQueryRows(query string, creds MySQLCredentials) ([][]string, error) {
+	mysqlPath := ClientToolPath()
+
+	// The credentials go into an option file, so the password never shows up
+	// in the process list
+	optionFile, err := writeOptionFile(ConnectionDetails{
+		Host:     creds.Host,
+		Port:     creds.Port,
+		User:     creds.Username,
+		Password: creds.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer removeOptionFile(optionFile)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(AppConfig.ConnectionTimeoutSecs)*time.Second)
+	defer cancel()
+
+	// --defaults-extra-file must come first; batch mode gives one
+	// tab-separated line per row
+	args := []string{"--defaults-extra-file=" + optionFile, "-B", "-N", "-e", query}
+
+	cmd := exec.CommandContext(ctx, mysqlPath, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("query failed: %v\nOutput: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("query failed: %v", err)
+	}
+
+	rows := [][]string{}
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\r\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+	return rows, nil
+}
+
 // FindProcessUsingPort finds which process is using a specific port
 func FindProcessUsingPort(port string) {
 	var cmd *exec.Cmd
@@ -247,11 +310,10 @@ func FindProcessUsingPort(port string) {
 
 // StopLinuxService stops the MariaDB service on Linux
 func StopLinuxService() error {
-	if AppConfig.RequireElevation {
-		cmd := exec.Command("sudo", "systemctl", "stop", AppConfig.ServiceNames["linux"])
-		return cmd.Run()
+	if AppConfig.RequireElevation && !IsRoot() {
+		return runElevatedHelper(nil, HelperSystemctl, "stop", GetDistroServiceName())
 	}
-	cmd := exec.Command("systemctl", "stop", AppConfig.ServiceNames["linux"])
+	cmd := exec.Command("systemctl", "stop", GetDistroServiceName())
 	return cmd.Run()
 }
 
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsHistorySize is the number of samples kept in memory for sparklines
+const metricsHistorySize = 60
+
+// HealthMetrics represents one sample of server health collected from SHOW GLOBAL STATUS
+type HealthMetrics struct {
+	Timestamp          time.Time         `json:"timestamp"`
+	ConfigName         string            `json:"config_name"`
+	UptimeSecs         int64             `json:"uptime_seconds"`
+	Connections        int64             `json:"connections"` // Total connection attempts
+	ThreadsConnected   int64             `json:"threads_connected"`
+	ThreadsRunning     int64             `json:"threads_running"`
+	Questions          int64             `json:"questions"`
+	QPS                float64           `json:"qps"` // Queries per second since the previous sample
+	SlowQueries        int64             `json:"slow_queries"`
+	BufferPoolHitRatio float64           `json:"buffer_pool_hit_ratio"` // Percentage, -1 when unknown
+	ReplicationState   string            `json:"replication_state"`
+	Status             map[string]string `json:"-"` // Raw SHOW GLOBAL STATUS values
+}
+
+var (
+	metricsHistory   []HealthMetrics
+	metricsHistoryMu sync.Mutex
+	lastMetricsError error
+
+	statusListeners   []func(MariaDBStatus)
+	statusListenersMu sync.Mutex
+)
+
+// OnStatusPolled registers a function called after every PollStatus, so other
+// subsystems can piggyback on the refresh loop instead of polling on their own
+func OnStatusPolled(listener func(MariaDBStatus)) {
+	statusListenersMu.Lock()
+	defer statusListenersMu.Unlock()
+	statusListeners = append(statusListeners, listener)
+}
+
+// PollStatus refreshes CurrentStatus and, when the server is running, collects a
+// health sample into the in-memory history. It is called at the refresh interval.
+func PollStatus() MariaDBStatus {
+	status := pollStatus()
+
+	statusListenersMu.Lock()
+	listeners := append([]func(MariaDBStatus){}, statusListeners...)
+	statusListenersMu.Unlock()
+
+	for _, listener := range listeners {
+		listener(status)
+	}
+	return status
+}
+
+// pollStatus does the actual status refresh and metrics collection
+func pollStatus() MariaDBStatus {
+	status := GetMariaDBStatus()
+	CurrentStatus = status
+
+	if !status.IsRunning {
+		ClearMetricsHistory()
+		return status
+	}
+
+	creds := GetRunningCredentials()
+
+	metrics, err := CollectHealthMetrics(creds, status.ConfigName)
+
+	metricsHistoryMu.Lock()
+	lastMetricsError = err
+	metricsHistoryMu.Unlock()
+
+	if err != nil {
+		AppLogger.Debug("Health metrics collection failed: %v", err)
+		return status
+	}
+
+	RecordHealthMetrics(*metrics)
+	return status
+}
+
+// CollectHealthMetrics queries SHOW GLOBAL STATUS and builds a health sample
+func CollectHealthMetrics(creds MySQLCredentials, configName string) (*HealthMetrics, error) {
+	rows, err := ExecMySQLQueryRows("SHOW GLOBAL STATUS", creds)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make(map[string]string, len(rows))
+	for _, row := range rows {
+		if len(row) >= 2 {
+			status[row[0]] = row[1]
+		}
+	}
+
+	metrics := &HealthMetrics{
+		Timestamp:          time.Now(),
+		ConfigName:         configName,
+		UptimeSecs:         statusInt(status, "Uptime"),
+		Connections:        statusInt(status, "Connections"),
+		ThreadsConnected:   statusInt(status, "Threads_connected"),
+		ThreadsRunning:     statusInt(status, "Threads_running"),
+		Questions:          statusInt(status, "Questions"),
+		SlowQueries:        statusInt(status, "Slow_queries"),
+		BufferPoolHitRatio: -1,
+		ReplicationState:   replicationState(status),
+		Status:             status,
+	}
+
+	if requests := statusInt(status, "Innodb_buffer_pool_read_requests"); requests > 0 {
+		reads := statusInt(status, "Innodb_buffer_pool_reads")
+		metrics.BufferPoolHitRatio = (1 - float64(reads)/float64(requests)) * 100
+	}
+
+	// QPS is the rate since the previous sample, or the lifetime average for the first one
+	if previous := LatestHealthMetrics(); previous != nil && previous.ConfigName == configName &&
+		metrics.Questions >= previous.Questions {
+		if elapsed := metrics.Timestamp.Sub(previous.Timestamp).Seconds(); elapsed > 0 {
+			metrics.QPS = float64(metrics.Questions-previous.Questions) / elapsed
+		}
+	} else if metrics.UptimeSecs > 0 {
+		metrics.QPS = float64(metrics.Questions) / float64(metrics.UptimeSecs)
+	}
+
+	return metrics, nil
+}
+
+// statusInt returns a numeric SHOW GLOBAL STATUS value, or 0 if missing
+func statusInt(status map[string]string, name string) int64 {
+	value, _ := strconv.ParseInt(strings.TrimSpace(status[name]), 10, 64)
+	return value
+}
+
+// replicationState summarizes replication from the status counters
+func replicationState(status map[string]string) string {
+	if strings.EqualFold(status["Slave_running"], "ON") || statusInt(status, "Slaves_running") > 0 {
+		return "Replica running"
+	}
+	if replicas := statusInt(status, "Slaves_connected"); replicas > 0 {
+		return fmt.Sprintf("Primary (%d replicas)", replicas)
+	}
+	return "Not replicating"
+}
+
+// RecordHealthMetrics appends a sample to the history. The history restarts when
+// the configuration changes or the server was restarted in between.
+func RecordHealthMetrics(metrics HealthMetrics) {
+	metricsHistoryMu.Lock()
+	defer metricsHistoryMu.Unlock()
+
+	if n := len(metricsHistory); n > 0 {
+		last := metricsHistory[n-1]
+		if last.ConfigName != metrics.ConfigName || metrics.UptimeSecs < last.UptimeSecs {
+			metricsHistory = nil
+		}
+	}
+
+	metricsHistory = append(metricsHistory, metrics)
+	if len(metricsHistory) > metricsHistorySize {
+		metricsHistory = metricsHistory[len(metricsHistory)-metricsHistorySize:]
+	}
+}
+
+// GetMetricsHistory returns a copy of the collected health samples, oldest first
+func GetMetricsHistory() []HealthMetrics {
+	metricsHistoryMu.Lock()
+	defer metricsHistoryMu.Unlock()
+
+	history := make([]HealthMetrics, len(metricsHistory))
+	copy(history, metricsHistory)
+	return history
+}
+
+// LatestHealthMetrics returns the most recent health sample, or nil if none
+func LatestHealthMetrics() *HealthMetrics {
+	metricsHistoryMu.Lock()
+	defer metricsHistoryMu.Unlock()
+
+	if len(metricsHistory) == 0 {
+		return nil
+	}
+	latest := metricsHistory[len(metricsHistory)-1]
+	return &latest
+}
+
+// LastMetricsError returns the error from the most recent collection attempt
+func LastMetricsError() error {
+	metricsHistoryMu.Lock()
+	defer metricsHistoryMu.Unlock()
+	return lastMetricsError
+}
+
+// ClearMetricsHistory discards all collected health samples
+func ClearMetricsHistory() {
+	metricsHistoryMu.Lock()
+	defer metricsHistoryMu.Unlock()
+	metricsHistory = nil
+	lastMetricsError = nil
+}
+
+// MetricsSeries extracts one value per sample for rendering a sparkline
+func MetricsSeries(history []HealthMetrics, value func(HealthMetrics) float64) []float64 {
+	series := make([]float64, 0, len(history))
+	for _, sample := range history {
+		series = append(series, value(sample))
+	}
+	return series
+}
+
+// FormatHealthMetrics renders the latest sample with sparklines of the history.
+// Used by both the status card and the terminal view.
+func FormatHealthMetrics(history []HealthMetrics) []string {
+	if len(history) == 0 {
+		return nil
+	}
+	latest := history[len(history)-1]
+
+	hitRatio := "n/a"
+	if latest.BufferPoolHitRatio >= 0 {
+		hitRatio = fmt.Sprintf("%.2f%%", latest.BufferPoolHitRatio)
+	}
+
+	return []string{
+		fmt.Sprintf("Uptime:          %s", FormatUptime(latest.UptimeSecs)),
+		fmt.Sprintf("Connections:     %-10d %s", latest.ThreadsConnected,
+			Sparkline(MetricsSeries(history, func(m HealthMetrics) float64 { return float64(m.ThreadsConnected) }))),
+		fmt.Sprintf("Threads running: %-10d %s", latest.ThreadsRunning,
+			Sparkline(MetricsSeries(history, func(m HealthMetrics) float64 { return float64(m.ThreadsRunning) }))),
+		fmt.Sprintf("QPS:             %-10.1f %s", latest.QPS,
+			Sparkline(MetricsSeries(history, func(m HealthMetrics) float64 { return m.QPS }))),
+		fmt.Sprintf("Slow queries:    %-10d %s", latest.SlowQueries,
+			Sparkline(MetricsSeries(history, func(m HealthMetrics) float64 { return float64(m.SlowQueries) }))),
+		fmt.Sprintf("Buffer pool hit: %-10s %s", hitRatio,
+			Sparkline(MetricsSeries(history, func(m HealthMetrics) float64 { return m.BufferPoolHitRatio }))),
+		fmt.Sprintf("Replication:     %s", latest.ReplicationState),
+	}
+}
@@ -154,16 +154,52 @@ func getLinuxIcon(notificationType NotificationType) string {
 
 // NotifyMariaDBStarted shows a notification when MariaDB starts successfully
 func NotifyMariaDBStarted(configName string) {
-	ShowNotification("MariaDB Started", 
-		fmt.Sprintf("MariaDB started successfully with configuration '%s'", configName), 
-		SuccessNotification)
+	message := fmt.Sprintf("MariaDB started successfully with configuration '%s'", configName)
+	ShowNotification("MariaDB Started", message, SuccessNotification)
+	DispatchNotification(NotificationMessage{
+		Event:      EventStarted,
+		Title:      "MariaDB Started",
+		Message:    message,
+		ConfigName: configName,
+	})
 }
 
 // NotifyMariaDBStopped shows a notification when MariaDB stops
-func NotifyMariaDBStopped() {
+func NotifyMariaDBStopped(configName string) {
 	ShowNotification("MariaDB Stopped", 
 		"MariaDB has been stopped", 
 		InfoNotification)
+	DispatchNotification(NotificationMessage{
+		Event:      EventStopped,
+		Title:      "MariaDB Stopped",
+		Message:    fmt.Sprintf("MariaDB running with configuration '%s' has been stopped", configName),
+		ConfigName: configName,
+	})
+}
+
+// NotifyMariaDBCrashed shows a notification when MariaDB exits unexpectedly
+func NotifyMariaDBCrashed(configName, details string) {
+	message := fmt.Sprintf("MariaDB running with configuration '%s' exited unexpectedly", configName)
+	if details != "" {
+		message += ": " + details
+	}
+	NotifyMariaDBError(message)
+	DispatchNotification(NotificationMessage{
+		Event:      EventCrashed,
+		Title:      "MariaDB Crashed",
+		Message:    message,
+		ConfigName: configName,
+	})
+}
+
+// NotifySwitchFailed notifies external channels when starting or switching to a configuration fails
+func NotifySwitchFailed(configName string, err error) {
+	DispatchNotification(NotificationMessage{
+		Event:      EventSwitchFailed,
+		Title:      "Configuration Switch Failed",
+		Message:    fmt.Sprintf("Failed to start configuration '%s': %v", configName, err),
+		ConfigName: configName,
+	})
 }
 
 // NotifyMariaDBError shows a notification when MariaDB encounters an error
@@ -178,4 +214,4 @@ func NotifyConfigurationSwitched(configName string) {
 	ShowNotification("Configuration Switched", 
 		fmt.Sprintf("Switched to configuration '%s'", configName), 
 		InfoNotification)
-}
\ No newline at end of file
+}
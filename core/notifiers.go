@@ -0,0 +1,365 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// notifierTimeout bounds how long a single channel may take to deliver a message
+const notifierTimeout = 10 * time.Second
+
+// Notification channel types
+const (
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+	ChannelEmail   = "email"
+	ChannelCommand = "command"
+)
+
+// NotificationEvent identifies what happened to the server
+type NotificationEvent string
+
+// Events that can be sent to notification channels
+const (
+	EventStarted      NotificationEvent = "started"
+	EventStopped      NotificationEvent = "stopped"
+	EventCrashed      NotificationEvent = "crashed"
+	EventSwitchFailed NotificationEvent = "switch_failed"
+	EventTest         NotificationEvent = "test"
+)
+
+// AllNotificationEvents lists the events a channel can filter on
+var AllNotificationEvents = []NotificationEvent{EventStarted, EventStopped, EventCrashed, EventSwitchFailed}
+
+// NotificationMessage is the payload delivered to notification channels.
+// Webhooks receive it as JSON.
+type NotificationMessage struct {
+	Event      NotificationEvent `json:"event"`
+	Title      string            `json:"title"`
+	Message    string            `json:"message"`
+	ConfigName string            `json:"config_name,omitempty"`
+	Hostname   string            `json:"hostname"`
+	Timestamp  time.Time         `json:"timestamp"`
+}
+
+// Notifier delivers a message to one external channel
+type Notifier interface {
+	Send(msg NotificationMessage) error
+}
+
+// NewNotifier creates the notifier for a configured channel
+func NewNotifier(channel NotificationChannel) (Notifier, error) {
+	switch channel.Type {
+	case ChannelWebhook:
+		if channel.URL == "" {
+			return nil, fmt.Errorf("webhook URL is required")
+		}
+		return &webhookNotifier{url: channel.URL}, nil
+	case ChannelSlack:
+		if channel.URL == "" {
+			return nil, fmt.Errorf("Slack webhook URL is required")
+		}
+		return &slackNotifier{url: channel.URL}, nil
+	case ChannelEmail:
+		if channel.SMTPHost == "" || channel.From == "" || len(channel.To) == 0 {
+			return nil, fmt.Errorf("SMTP host, sender and at least one recipient are required")
+		}
+		password := channel.SMTPPassword
+		if password == "" {
+			var err error
+			if password, err = LoadChannelSecret(channel.Name); err != nil {
+				AppLogger.Warn("Could not load SMTP password for %s: %v", channel.Name, err)
+			}
+		}
+		return &emailNotifier{channel: channel, password: password}, nil
+	case ChannelCommand:
+		if strings.TrimSpace(channel.Command) == "" {
+			return nil, fmt.Errorf("command is required")
+		}
+		return &commandNotifier{command: channel.Command}, nil
+	default:
+		return nil, fmt.Errorf("unknown channel type '%s'", channel.Type)
+	}
+}
+
+// WantsEvent reports whether the channel is subscribed to an event
+func (c NotificationChannel) WantsEvent(event NotificationEvent) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if NotificationEvent(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingNotifications tracks deliveries still in progress
+var pendingNotifications sync.WaitGroup
+
+// DispatchNotification sends a message to every enabled channel subscribed to
+// its event. Channels are notified in the background, so a slow endpoint never
+// holds up a start or stop; failures are logged.
+func DispatchNotification(msg NotificationMessage) {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	if msg.Hostname == "" {
+		msg.Hostname, _ = os.Hostname()
+	}
+
+	for _, channel := range AppConfig.NotificationChannels {
+		if !channel.Enabled || !channel.WantsEvent(msg.Event) {
+			continue
+		}
+
+		pendingNotifications.Add(1)
+		go func(channel NotificationChannel) {
+			defer pendingNotifications.Done()
+			if err := sendToChannel(channel, msg); err != nil {
+				AppLogger.Error("Notification channel '%s' failed: %v", channel.Name, err)
+			} else {
+				AppLogger.Debug("Notification '%s' sent to channel '%s'", msg.Event, channel.Name)
+			}
+		}(channel)
+	}
+}
+
+// WaitForNotifications waits until dispatched notifications are delivered or
+// have failed. Each channel is bounded by notifierTimeout. Short-lived
+// commands call it before exiting.
+func WaitForNotifications() {
+	pendingNotifications.Wait()
+}
+
+// TestNotificationChannel sends a test message to a channel, ignoring its
+// enabled flag and event filter
+func TestNotificationChannel(channel NotificationChannel) error {
+	hostname, _ := os.Hostname()
+	return sendToChannel(channel, NotificationMessage{
+		Event:     EventTest,
+		Title:     "DBSwitcher Test",
+		Message:   fmt.Sprintf("Test notification from DBSwitcher on %s", hostname),
+		Hostname:  hostname,
+		Timestamp: time.Now(),
+	})
+}
+
+// sendToChannel builds the notifier for a channel and sends a message
+func sendToChannel(channel NotificationChannel, msg NotificationMessage) error {
+	notifier, err := NewNotifier(channel)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(msg)
+}
+
+// webhookNotifier POSTs the message as JSON
+type webhookNotifier struct {
+	url string
+}
+
+func (n *webhookNotifier) Send(msg NotificationMessage) error {
+	return postJSON(n.url, msg)
+}
+
+// slackNotifier posts to a Slack-compatible incoming webhook
+type slackNotifier struct {
+	url string
+}
+
+func (n *slackNotifier) Send(msg NotificationMessage) error {
+	text := fmt.Sprintf("*%s*\n%s", msg.Title, msg.Message)
+	if msg.Hostname != "" {
+		text += fmt.Sprintf("\n_Host: %s_", msg.Hostname)
+	}
+	return postJSON(n.url, map[string]string{"text": text})
+}
+
+// postJSON sends a JSON body and treats any non-2xx response as an error
+func postJSON(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %v", err)
+	}
+
+	client := &http.Client{Timeout: notifierTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	return nil
+}
+
+// emailNotifier sends a plain-text email over SMTP (STARTTLS when offered)
+type emailNotifier struct {
+	channel  NotificationChannel
+	password string
+}
+
+func (n *emailNotifier) Send(msg NotificationMessage) error {
+	port := n.channel.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(n.channel.SMTPHost, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if n.channel.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", n.channel.SMTPUsername, n.password, n.channel.SMTPHost)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", n.channel.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(n.channel.To, ", "))
+	fmt.Fprintf(&body, "Subject: [DBSwitcher] %s\r\n", msg.Title)
+	fmt.Fprintf(&body, "Date: %s\r\n", msg.Timestamp.Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&body, "%s\r\n\r\nEvent: %s\r\nHost: %s\r\n", msg.Message, msg.Event, msg.Hostname)
+	if msg.ConfigName != "" {
+		fmt.Fprintf(&body, "Configuration: %s\r\n", msg.ConfigName)
+	}
+
+	return sendMail(addr, auth, n.channel.From, n.channel.To, []byte(body.String()))
+}
+
+// sendMail works like smtp.SendMail, but the whole exchange must finish
+// within notifierTimeout
+func sendMail(addr string, auth smtp.Auth, from string, to []string, message []byte) error {
+	conn, err := net.DialTimeout("tcp", addr, notifierTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(notifierTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	host, _, _ := net.SplitHostPort(addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// commandNotifier runs a local command with the message in its environment
+// and as JSON on stdin
+type commandNotifier struct {
+	command string
+}
+
+func (n *commandNotifier) Send(msg NotificationMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifierTimeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, n.command)
+	cmd.Env = append(os.Environ(),
+		"DBSWITCHER_EVENT="+string(msg.Event),
+		"DBSWITCHER_TITLE="+msg.Title,
+		"DBSWITCHER_MESSAGE="+msg.Message,
+		"DBSWITCHER_CONFIG_NAME="+msg.ConfigName,
+	)
+	cmd.Stdin = bytes.NewReader(payload)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", notifierTimeout)
+	}
+	if err != nil {
+		if out := strings.TrimSpace(string(output)); out != "" {
+			return fmt.Errorf("%v: %s", err, lastLine(out))
+		}
+		return err
+	}
+	return nil
+}
+
+// channelSecretAccount returns the keyring account for a channel's secret
+func channelSecretAccount(channelName string) string {
+	return "notification_channel:" + channelName
+}
+
+// SaveChannelSecret stores a channel's password in the system keyring
+func SaveChannelSecret(channelName, secret string) error {
+	if err := keyring.Set(KeyringService, channelSecretAccount(channelName), secret); err != nil {
+		return fmt.Errorf("failed to save to keyring: %v", err)
+	}
+	return nil
+}
+
+// LoadChannelSecret loads a channel's password from the system keyring
+func LoadChannelSecret(channelName string) (string, error) {
+	secret, err := keyring.Get(KeyringService, channelSecretAccount(channelName))
+	if err == keyring.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load from keyring: %v", err)
+	}
+	return secret, nil
+}
+
+// DeleteChannelSecret removes a channel's password from the system keyring
+func DeleteChannelSecret(channelName string) error {
+	err := keyring.Delete(KeyringService, channelSecretAccount(channelName))
+	if err != nil && err != keyring.ErrNotFound {
+		return fmt.Errorf("failed to delete from keyring: %v", err)
+	}
+	return nil
+}
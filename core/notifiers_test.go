@@ -0,0 +1,89 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifierPostsMessage(t *testing.T) {
+	AppLogger = &Logger{}
+
+	received := make(chan NotificationMessage, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		var msg NotificationMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		received <- msg
+	}))
+	defer server.Close()
+
+	err := sendToChannel(NotificationChannel{Name: "hook", Type: ChannelWebhook, URL: server.URL},
+		NotificationMessage{Event: EventStarted, Title: "MariaDB Started", ConfigName: "dev"})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	msg := <-received
+	if msg.Event != EventStarted || msg.ConfigName != "dev" {
+		t.Errorf("received %+v", msg)
+	}
+}
+
+func TestWebhookNotifierReportsHTTPErrors(t *testing.T) {
+	AppLogger = &Logger{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := sendToChannel(NotificationChannel{Name: "hook", Type: ChannelWebhook, URL: server.URL},
+		NotificationMessage{Event: EventStopped})
+	if err == nil {
+		t.Fatal("expected an error for a 500 response")
+	}
+}
+
+func TestDispatchNotificationDoesNotBlock(t *testing.T) {
+	AppLogger = &Logger{}
+
+	release := make(chan struct{})
+	hits := make(chan NotificationEvent, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg NotificationMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		<-release
+		hits <- msg.Event
+	}))
+	defer server.Close()
+
+	saved := AppConfig.NotificationChannels
+	defer func() { AppConfig.NotificationChannels = saved }()
+	AppConfig.NotificationChannels = []NotificationChannel{
+		{Name: "slow", Type: ChannelWebhook, URL: server.URL, Enabled: true},
+		{Name: "filtered", Type: ChannelWebhook, URL: server.URL, Enabled: true, Events: []string{string(EventCrashed)}},
+		{Name: "disabled", Type: ChannelWebhook, URL: server.URL},
+	}
+
+	started := time.Now()
+	DispatchNotification(NotificationMessage{Event: EventStarted, Title: "MariaDB Started"})
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("DispatchNotification blocked for %v", elapsed)
+	}
+
+	close(release)
+	WaitForNotifications()
+	if len(hits) != 1 {
+		t.Fatalf("delivered to %d channels, want 1", len(hits))
+	}
+	if event := <-hits; event != EventStarted {
+		t.Errorf("event = %s, want %s", event, EventStarted)
+	}
+}
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// onDemandInterval is how often on-demand configurations are checked for
+// ports to listen on and idle servers to stop
+const onDemandInterval = 10 * time.Second
+
+// onDemandListener holds the port of a stopped on-demand configuration until
+// the server started by its first client takes it over
+type onDemandListener struct {
+	config   MariaDBConfig
+	listener net.Listener
+	starting bool       // A client connected and the server is being started
+	queued   []net.Conn // Clients waiting for the server to start, guarded by onDemandMu
+}
+
+var (
+	onDemandListeners = map[string]*onDemandListener{} // By config path
+	onDemandIdleSince time.Time                        // When the running on-demand server was last seen without clients
+	onDemandStarting  bool                             // A client's connection is starting a server
+	onDemandStopped   bool
+	onDemandMu        sync.Mutex
+	onDemandOnce      sync.Once
+)
+
+// IsOnDemandConfig reports whether a configuration is started by its first
+// client connection and stopped again when idle. It is enabled in the
+// [dbswitcher] group:
+//
+//	on-demand = yes
+//	idle-stop = 900    # seconds without client connections before stopping (default 900)
+func IsOnDemandConfig(cfg MariaDBConfig) bool {
+	return isEnabledOption(cfg.Options["on-demand"])
+}
+
+// OnDemandIdleTimeout returns how long an on-demand configuration may run
+// without client connections
+func OnDemandIdleTimeout(cfg MariaDBConfig) time.Duration {
+	return optionSeconds(cfg, "idle-stop", 900)
+}
+
+// StartOnDemand starts listening on the ports of stopped on-demand
+// configurations and stopping idle ones. It is meant for long-running
+// processes (GUI and tray); calling it again is a no-op.
+func StartOnDemand() {
+	onDemandOnce.Do(func() {
+		go func() {
+			checkOnDemandConfigs()
+			ticker := time.NewTicker(onDemandInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				checkOnDemandConfigs()
+			}
+		}()
+		AppLogger.Info("On-demand activation started")
+	})
+}
+
+// StopOnDemand releases the ports held for on-demand configurations and stops
+// watching them
+func StopOnDemand() {
+	onDemandMu.Lock()
+	defer onDemandMu.Unlock()
+
+	onDemandStopped = true
+	for key, od := range onDemandListeners {
+		od.listener.Close()
+		delete(onDemandListeners, key)
+	}
+}
+
+// releaseOnDemandPort closes the on-demand listener holding a port, so a
+// server being started can bind it
+func releaseOnDemandPort(port string) {
+	onDemandMu.Lock()
+	defer onDemandMu.Unlock()
+
+	for key, od := range onDemandListeners {
+		if od.config.Port == port {
+			AppLogger.Debug("Releasing port %s held for on-demand configuration %s", port, od.config.Name)
+			od.listener.Close()
+			delete(onDemandListeners, key)
+		}
+	}
+}
+
+// checkOnDemandConfigs listens on the ports of stopped on-demand
+// configurations and stops the running one once it has been idle long enough
+func checkOnDemandConfigs() {
+	wanted := map[string]MariaDBConfig{}
+	for _, cfg := range AvailableConfigs {
+		if IsOnDemandConfig(cfg) && cfg.Port != "" {
+			wanted[filepath.Clean(cfg.Path)] = cfg
+		}
+	}
+
+	onDemandMu.Lock()
+	if onDemandStopped || onDemandStarting {
+		onDemandMu.Unlock()
+		return
+	}
+	// Drop listeners of configurations that are gone, no longer on demand or changed port
+	for key, od := range onDemandListeners {
+		if cfg, ok := wanted[key]; !ok || cfg.Port != od.config.Port {
+			od.listener.Close()
+			delete(onDemandListeners, key)
+		}
+	}
+	onDemandMu.Unlock()
+
+	if len(wanted) == 0 {
+		return
+	}
+
+	status := GetMariaDBStatus()
+	var running *MariaDBConfig
+	if status.IsRunning && status.ConfigFile != "" {
+		if cfg, ok := wanted[filepath.Clean(status.ConfigFile)]; ok {
+			running = &cfg
+		}
+	}
+
+	onDemandMu.Lock()
+	for key, cfg := range wanted {
+		if _, ok := onDemandListeners[key]; ok || (running != nil && key == filepath.Clean(running.Path)) {
+			continue
+		}
+		// Another server or program may be using the port
+		if !IsPortAvailable(cfg.Port) {
+			continue
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", cfg.Port))
+		if err != nil {
+			AppLogger.Debug("Cannot listen on port %s for on-demand configuration %s: %v", cfg.Port, cfg.Name, err)
+			continue
+		}
+		od := &onDemandListener{config: cfg, listener: listener}
+		onDemandListeners[key] = od
+		go acceptOnDemandConnections(key, od)
+		AppLogger.Info("Waiting for clients of on-demand configuration %s on port %s", cfg.Name, cfg.Port)
+	}
+	onDemandMu.Unlock()
+
+	if running == nil {
+		onDemandIdleSince = time.Time{}
+		return
+	}
+	if !isServerIdle() {
+		onDemandIdleSince = time.Time{}
+		return
+	}
+	if onDemandIdleSince.IsZero() {
+		onDemandIdleSince = time.Now()
+		return
+	}
+	timeout := OnDemandIdleTimeout(*running)
+	if time.Since(onDemandIdleSince) < timeout {
+		return
+	}
+
+	AppLogger.Info("On-demand configuration %s has had no clients for %v, stopping it", running.Name, timeout)
+	onDemandIdleSince = time.Time{}
+	if err := StopMySQLWithCredentials(GetCredentialsForConfig(*running)); err != nil {
+		AppLogger.Error("Failed to stop idle configuration %s: %v", running.Name, err)
+	}
+}
+
+// isServerIdle reports whether the running server has no client connections
+// besides the one asking. A server that can't be asked counts as busy.
+func isServerIdle() bool {
+	rows, err := ExecMySQLQueryRows("SHOW GLOBAL STATUS LIKE 'Threads_connected'", GetRunningCredentials())
+	if err != nil {
+		AppLogger.Debug("Cannot check on-demand server for clients: %v", err)
+		return false
+	}
+	for _, row := range rows {
+		if len(row) >= 2 && strings.EqualFold(row[0], "Threads_connected") {
+			threads, err := strconv.Atoi(strings.TrimSpace(row[1]))
+			return err == nil && threads <= 1
+		}
+	}
+	return false
+}
+
+// acceptOnDemandConnections waits for clients of a stopped on-demand
+// configuration. The first one starts the server; it and every client
+// arriving until the server takes the port are queued and forwarded once the
+// server is ready.
+func acceptOnDemandConnections(key string, od *onDemandListener) {
+	for {
+		client, err := od.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				AppLogger.Error("Stopped waiting for clients of %s: %v", od.config.Name, err)
+			}
+			return
+		}
+
+		onDemandMu.Lock()
+		switch {
+		case od.starting:
+			od.queued = append(od.queued, client)
+			onDemandMu.Unlock()
+		case onDemandListeners[key] != od || onDemandStarting:
+			onDemandMu.Unlock()
+			client.Close()
+		default:
+			od.starting = true
+			od.queued = append(od.queued, client)
+			onDemandStarting = true
+			onDemandMu.Unlock()
+			go startOnDemandConfig(key, od)
+		}
+	}
+}
+
+// startOnDemandConfig starts a configuration for the clients that connected
+// to its port, then forwards them to the server or, if it couldn't be
+// started, disconnects them
+func startOnDemandConfig(key string, od *onDemandListener) {
+	started := startOnDemandServer(od.config)
+
+	onDemandMu.Lock()
+	onDemandStarting = false
+	od.starting = false
+	clients := od.queued
+	od.queued = nil
+	// A start that failed before taking the port leaves it to the next check
+	if onDemandListeners[key] == od {
+		od.listener.Close()
+		delete(onDemandListeners, key)
+	}
+	onDemandMu.Unlock()
+
+	for _, client := range clients {
+		if !started {
+			client.Close()
+			continue
+		}
+		go forwardOnDemandClient(od.config, client)
+	}
+}
+
+// startOnDemandServer starts a configuration whose port a client connected to
+// and reports whether it is running
+func startOnDemandServer(cfg MariaDBConfig) bool {
+	if IsMariaDBRunning() {
+		AppLogger.Warn("Client connected to on-demand configuration %s, but another server is running", cfg.Name)
+		return false
+	}
+
+	AppLogger.Info("Client connected to on-demand configuration %s, starting it", cfg.Name)
+	if err := StartMariaDBWithConfig(cfg.Path); err != nil {
+		AppLogger.Error("Failed to start on-demand configuration %s: %v", cfg.Name, err)
+		NotifySwitchFailed(cfg.Name, err)
+		NotifyMariaDBError(err.Error())
+		return false
+	}
+	return true
+}
+
+// forwardOnDemandClient connects a client that waited for an on-demand
+// configuration to start to its server
+func forwardOnDemandClient(cfg MariaDBConfig, client net.Conn) {
+	defer client.Close()
+
+	server, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", cfg.Port), time.Duration(AppConfig.ConnectionTimeoutSecs)*time.Second)
+	if err != nil {
+		AppLogger.Error("Started %s but could not forward a waiting client: %v", cfg.Name, err)
+		return
+	}
+	defer server.Close()
+	pipeConnections(client, server)
+}
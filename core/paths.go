@@ -32,6 +32,13 @@ func GetAppDataDir() string {
 	return appDir
 }
 
+// GetConsoleLogPath returns the file receiving a started server's console output
+func GetConsoleLogPath(configName string) string {
+	logDir := filepath.Join(GetAppDataDir(), "logs")
+	os.MkdirAll(logDir, 0755)
+	return filepath.Join(logDir, configName+".console.log")
+}
+
 // GetUserConfigDir returns the user config directory (for MariaDB configs)
 func GetUserConfigDir() string {
 	var dir string
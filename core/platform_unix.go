@@ -0,0 +1,74 @@
+//go:build !windows
+
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// configureDetachedProcess starts the server in its own process group so a
+// Ctrl+C in the terminal that ran DBSwitcher does not stop it
+func configureDetachedProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// fileOwnerUID returns the uid owning a file, without following symlinks
+func fileOwnerUID(path string) (int, bool) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Uid), true
+}
+
+// fileOwnerGID returns the gid owning a file, without following symlinks
+func fileOwnerGID(path string) (int, bool) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Gid), true
+}
+
+// isMountPoint reports whether a directory is the root of a mounted
+// filesystem, i.e. lives on a different device than its parent
+func isMountPoint(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	parent, err := os.Stat(filepath.Join(dir, ".."))
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	parentStat, parentOK := parent.Sys().(*syscall.Stat_t)
+	if !ok || !parentOK {
+		return false
+	}
+	return stat.Dev != parentStat.Dev || stat.Ino == parentStat.Ino
+}
+
+// terminateProcess asks a server to shut down cleanly
+func terminateProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
+
+// TerminalSupportsANSI reports whether f is a terminal that understands ANSI
+// escape codes
+func TerminalSupportsANSI(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd())) && os.Getenv("TERM") != "dumb"
+}
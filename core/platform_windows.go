@@ -0,0 +1,63 @@
+//go:build windows
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// configureDetachedProcess hides the console window and starts the server in a
+// new process group so it survives termination of this process
+func configureDetachedProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,       // Hide console window
+		CreationFlags: 0x00000200, // CREATE_NEW_PROCESS_GROUP - allows process to survive parent termination
+	}
+}
+
+// fileOwnerUID is not available on Windows, where ownership is checked through ACLs
+func fileOwnerUID(path string) (int, bool) {
+	return 0, false
+}
+
+// fileOwnerGID is not available on Windows, where ownership is checked through ACLs
+func fileOwnerGID(path string) (int, bool) {
+	return 0, false
+}
+
+// changeDataDirOwner is only needed by the elevated helper, which doesn't run on Windows
+func changeDataDirOwner(dir string, uid, gid int) error {
+	return fmt.Errorf("changing the owner of %s is not supported on Windows", dir)
+}
+
+// isMountPoint is not needed on Windows, where a drive that isn't attached
+// has no directories at all
+func isMountPoint(dir string) bool {
+	return false
+}
+
+// terminateProcess stops a server. Windows has no SIGTERM, and console control
+// events can't reach a process without a console.
+func terminateProcess(process *os.Process) error {
+	return process.Kill()
+}
+
+// TerminalSupportsANSI reports whether f is a console that understands ANSI
+// escape codes, turning them on where the console has them off. Consoles
+// before Windows 10 don't have them.
+func TerminalSupportsANSI(f *os.File) bool {
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return false
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// maxOwnershipChecks bounds how many data directory entries pre-flight inspects
+const maxOwnershipChecks = 200
+
+// OwnershipError reports a data directory that is not owned by the OS user the
+// server runs as. It can be fixed with FixDataDirOwnership.
+type OwnershipError struct {
+	ConfigName string
+	DataDir    string
+	Path       string // First entry with the wrong owner
+	Owner      string
+	RunUser    string
+}
+
+func (e *OwnershipError) Error() string {
+	return fmt.Sprintf("%s is owned by '%s' but the server runs as '%s' - fix the ownership with 'dbswitcher fix-perms %s'",
+		e.Path, e.Owner, e.RunUser, e.ConfigName)
+}
+
+// ServerRunUser returns the OS user a configuration's server runs as. The user=
+// option only takes effect when mysqld is started as root, i.e. by DBSwitcher
+// itself running as root; services, including system units and drop-ins, run
+// as the user running DBSwitcher.
+func ServerRunUser(cfg MariaDBConfig) string {
+	if IsRoot() {
+		if cfg.User != "" {
+			return cfg.User
+		}
+		return "root"
+	}
+	return currentUserName()
+}
+
+// PreflightCheck verifies that a configuration can start: pending data directory
+// upgrades, privileged ports, the OS user the server runs as and the ownership
+// of its data directory. It returns
+// warnings to log and the first problem that would make the start fail.
+func PreflightCheck(cfg MariaDBConfig) ([]string, error) {
+	warnings := []string{}
+
+	// The container image runs the server as its own user and fixes ownership itself
+	if IsContainerConfig(cfg) {
+		return warnings, nil
+	}
+
+	if upgrade := CheckUpgradeNeeded(cfg); upgrade.Needed {
+		warnings = append(warnings, upgrade.Warning(cfg.Name))
+	}
+
+	if err := CheckStartPrivileges(cfg); err != nil {
+		return warnings, err
+	}
+	if runtime.GOOS == "windows" {
+		return warnings, nil
+	}
+
+	runUser := ServerRunUser(cfg)
+	if cfg.User != "" && cfg.User != runUser {
+		warnings = append(warnings, fmt.Sprintf("user=%s is ignored because the server is not started as root; it runs as '%s'",
+			cfg.User, runUser))
+	}
+	if runUser == "root" && cfg.User == "" {
+		return warnings, fmt.Errorf("mysqld refuses to run as root - set user= in the [mysqld] section of %s (e.g. user=mysql)", cfg.Path)
+	}
+
+	account, err := user.Lookup(runUser)
+	if err != nil {
+		return warnings, fmt.Errorf("the server's OS user '%s' does not exist", runUser)
+	}
+
+	dataDir := ResolveDataDir(cfg)
+	if dataDir == "" || !PathExists(dataDir) {
+		return warnings, nil
+	}
+	if path, owner, ok := findForeignOwner(dataDir, account.Uid, account.Gid); !ok {
+		return warnings, &OwnershipError{
+			ConfigName: cfg.Name,
+			DataDir:    dataDir,
+			Path:       path,
+			Owner:      owner,
+			RunUser:    ownerName(account.Uid, account.Gid),
+		}
+	}
+	return warnings, nil
+}
+
+// findForeignOwner checks the data directory and its top-level entries against
+// the expected uid and gid. It returns the first mismatching path and its owner
+// as user:group. lost+found is skipped: it belongs to root on a data directory
+// that is a mount point, and the server ignores it.
+func findForeignOwner(dataDir, expectedUID, expectedGID string) (string, string, bool) {
+	paths := []string{dataDir}
+	if entries, err := os.ReadDir(dataDir); err == nil {
+		for i, entry := range entries {
+			if i >= maxOwnershipChecks {
+				break
+			}
+			if entry.Name() == "lost+found" {
+				continue
+			}
+			paths = append(paths, filepath.Join(dataDir, entry.Name()))
+		}
+	}
+
+	for _, path := range paths {
+		uid, ok := fileOwnerUID(path)
+		if !ok {
+			continue
+		}
+		gid, ok := fileOwnerGID(path)
+		if !ok {
+			continue
+		}
+		if strconv.Itoa(uid) == expectedUID && strconv.Itoa(gid) == expectedGID {
+			continue
+		}
+		return path, ownerName(strconv.Itoa(uid), strconv.Itoa(gid)), false
+	}
+	return "", "", true
+}
+
+// ownerName formats a uid and gid as user:group, using names where they resolve
+func ownerName(uid, gid string) string {
+	if u, err := user.LookupId(uid); err == nil {
+		uid = u.Username
+	}
+	if g, err := user.LookupGroupId(gid); err == nil {
+		gid = g.Name
+	}
+	return uid + ":" + gid
+}
+
+// FixDataDirOwnership gives a configuration's data directory to the OS user the
+// server runs as and makes sure that user can read and write all of it. It runs
+// through the elevated helper.
+func FixDataDirOwnership(cfg MariaDBConfig) error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("fixing data directory ownership is not supported on Windows")
+	}
+
+	dataDir := ResolveDataDir(cfg)
+	if dataDir == "" {
+		return fmt.Errorf("configuration '%s' has no data directory", cfg.Name)
+	}
+	if !PathExists(dataDir) {
+		return fmt.Errorf("data directory %s does not exist", dataDir)
+	}
+
+	configFile, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("cannot get absolute path for config: %v", err)
+	}
+
+	runUser := ServerRunUser(cfg)
+	AppLogger.Info("Fixing ownership of %s for user '%s'", dataDir, runUser)
+	if err := runElevatedHelper(nil, HelperFixDataDir, configFile, runUser); err != nil {
+		return fmt.Errorf("failed to fix ownership of %s: %v", dataDir, err)
+	}
+	return nil
+}
+
+// currentUserName returns the name of the user running DBSwitcher
+func currentUserName() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// currentGroupName returns the primary group of the user running DBSwitcher
+func currentGroupName() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	group, err := user.LookupGroupId(u.Gid)
+	if err != nil {
+		return ""
+	}
+	return group.Name
+}
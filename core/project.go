@@ -0,0 +1,153 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ProjectFileName is the file that binds a directory tree to a configuration
+const ProjectFileName = ".dbswitcher.toml"
+
+// ProjectFile is a project's database requirement:
+//
+//	config = "shop"          # configuration the project needs
+//	database = "shop"        # optional, defaults to the configuration's init-database
+//
+//	[env]                    # optional, names of the variables to set ("" leaves one out)
+//	host = "DB_HOST"
+//	port = "DB_PORT"
+//	socket = "DB_SOCKET"
+//	user = "DB_USER"
+//	password = "DB_PASSWORD"
+//	database = "DB_NAME"
+//	url = "DATABASE_URL"
+type ProjectFile struct {
+	Path     string            `toml:"-"`
+	Config   string            `toml:"config"`
+	Database string            `toml:"database"`
+	Env      map[string]string `toml:"env"`
+}
+
+// EnvVar is a variable of a project's connection environment
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// projectEnvKeys are the keys of the [env] table in the order the variables
+// are set, with their default names
+var projectEnvKeys = []EnvVar{
+	{"host", "DB_HOST"},
+	{"port", "DB_PORT"},
+	{"socket", "DB_SOCKET"},
+	{"user", "DB_USER"},
+	{"password", "DB_PASSWORD"},
+	{"database", "DB_NAME"},
+	{"url", "DATABASE_URL"},
+}
+
+// envNamePattern matches the variable names a shell can export
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// FindProjectFile returns the project file in dir or the closest parent
+// directory that has one, or an empty string if there is none
+func FindProjectFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectFile reads a project file. Unknown keys are errors, so a typo
+// doesn't silently fall back to a default.
+func LoadProjectFile(path string) (*ProjectFile, error) {
+	project := &ProjectFile{Path: path}
+	meta, err := toml.DecodeFile(path, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("%s: unknown key %s", path, undecoded[0])
+	}
+	for key, name := range project.Env {
+		if !isProjectEnvKey(key) {
+			return nil, fmt.Errorf("%s: unknown key env.%s", path, key)
+		}
+		if name != "" && !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("%s: env.%s = %q is not a valid variable name", path, key, name)
+		}
+	}
+	if project.Config == "" {
+		return nil, fmt.Errorf("%s doesn't name a config", path)
+	}
+	return project, nil
+}
+
+// isProjectEnvKey reports whether key is a key of the [env] table
+func isProjectEnvKey(key string) bool {
+	for _, known := range projectEnvKeys {
+		if known.Name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// FindProjectConfig returns the configuration a project file names
+func FindProjectConfig(project *ProjectFile) (*MariaDBConfig, error) {
+	for _, cfg := range AvailableConfigs {
+		if strings.EqualFold(cfg.Name, project.Config) {
+			return &cfg, nil
+		}
+	}
+	return nil, fmt.Errorf("%s needs configuration '%s', which doesn't exist", project.Path, project.Config)
+}
+
+// ProjectEnvironment returns the variables that connect a project's
+// applications to its configuration, in a fixed order
+func ProjectEnvironment(project *ProjectFile, cfg MariaDBConfig) []EnvVar {
+	details := GetConnectionDetails(cfg)
+	if project.Database != "" {
+		details.Database = project.Database
+	}
+
+	values := map[string]string{
+		"host":     details.Host,
+		"port":     details.Port,
+		"socket":   details.Socket,
+		"user":     details.User,
+		"password": details.Password,
+		"database": details.Database,
+		"url":      details.URL(true),
+	}
+
+	var env []EnvVar
+	for _, key := range projectEnvKeys {
+		name := key.Value
+		if custom, ok := project.Env[key.Name]; ok {
+			name = custom
+		}
+		// An empty password is still set, so a stale one doesn't linger
+		if name == "" || (values[key.Name] == "" && key.Name != "password") {
+			continue
+		}
+		env = append(env, EnvVar{Name: name, Value: values[key.Name]})
+	}
+	return env
+}
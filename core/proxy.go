@@ -0,0 +1,322 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultProxyListenAddr is the default front address of the proxy: the
+// standard port, so clients keep working whichever configuration is active.
+// Configurations then need other ports.
+const DefaultProxyListenAddr = "127.0.0.1:3306"
+
+var (
+	proxyListener net.Listener
+	proxyAddr     string // Address proxyListener listens on
+	proxyBackend  string // Address of the active configuration, empty while none is ready
+	proxyDraining bool   // A stop is draining the proxy; status polls leave the backend alone
+	proxyMu       sync.Mutex
+	proxyHookOnce sync.Once
+
+	// proxyActive counts the connections being forwarded, including those
+	// still connecting to the backend
+	proxyActive int64
+
+	// proxyConns holds the forwarded connections, so a drain can close idle ones
+	proxyConns   = make(map[*proxyConn]struct{})
+	proxyConnsMu sync.Mutex
+
+	// proxyPortClash is the configuration last warned about for using the
+	// proxy's port, so the warning isn't repeated on every poll
+	proxyPortClash string
+)
+
+// proxyIdleAfter is how long a connection must be quiet after the server's
+// last reply before a drain treats it as idle
+const proxyIdleAfter = time.Second
+
+// StartProxy starts the TCP proxy if it is enabled in settings. It forwards
+// each new connection to the configuration that is active at that moment.
+func StartProxy() error {
+	if !AppConfig.ProxyEnabled {
+		AppLogger.Debug("Proxy is disabled")
+		return nil
+	}
+
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+
+	if proxyListener != nil {
+		return nil
+	}
+
+	// Follow the active configuration on each status poll
+	proxyHookOnce.Do(func() {
+		OnStatusPolled(UpdateProxyBackend)
+	})
+
+	addr := proxyListenAddr()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", addr, err)
+	}
+	proxyListener = listener
+	proxyAddr = addr
+	if !proxyDraining {
+		proxyBackend = proxyBackendFor(CurrentStatus)
+	}
+
+	go acceptProxyConnections(listener)
+	AppLogger.Info("Proxy listening on %s", listener.Addr())
+	return nil
+}
+
+// StopProxy stops accepting connections. Connections being forwarded are left
+// to finish.
+func StopProxy() {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+
+	if proxyListener == nil {
+		return
+	}
+	proxyListener.Close()
+	proxyListener = nil
+	proxyAddr = ""
+	proxyBackend = ""
+	AppLogger.Info("Proxy stopped")
+}
+
+// RestartProxy applies changed proxy settings. The proxy is left alone if
+// they didn't change.
+func RestartProxy() error {
+	proxyMu.Lock()
+	running, addr := proxyListener != nil, proxyAddr
+	proxyMu.Unlock()
+	if running == AppConfig.ProxyEnabled && (!running || addr == proxyListenAddr()) {
+		return nil
+	}
+
+	StopProxy()
+	return StartProxy()
+}
+
+// proxyListenAddr returns the address the proxy should listen on
+func proxyListenAddr() string {
+	if AppConfig.ProxyListenAddr == "" {
+		return DefaultProxyListenAddr
+	}
+	return AppConfig.ProxyListenAddr
+}
+
+// UpdateProxyBackend points new proxy connections at the server described by
+// status, or refuses them if it isn't running
+func UpdateProxyBackend(status MariaDBStatus) {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+
+	if proxyListener == nil || proxyDraining {
+		return
+	}
+	backend := proxyBackendFor(status)
+	if backend == proxyBackend {
+		return
+	}
+	proxyBackend = backend
+	if backend == "" {
+		AppLogger.Info("Proxy has no backend, refusing new connections")
+	} else {
+		AppLogger.Info("Proxy now forwards to %s (%s)", backend, status.ConfigName)
+	}
+}
+
+// proxyBackendFor returns the address the proxy forwards to for a status. The
+// caller must hold proxyMu.
+func proxyBackendFor(status MariaDBStatus) string {
+	if !status.IsRunning || status.Port == "" {
+		return ""
+	}
+	if _, port, err := net.SplitHostPort(proxyListener.Addr().String()); err == nil && port == status.Port {
+		if proxyPortClash != status.ConfigName {
+			AppLogger.Warn("Configuration %s uses the proxy's port %s, not forwarding to it", status.ConfigName, port)
+			proxyPortClash = status.ConfigName
+		}
+		return ""
+	}
+	proxyPortClash = ""
+	return net.JoinHostPort("127.0.0.1", status.Port)
+}
+
+// DrainProxy refuses new proxy connections and waits up to timeout for the
+// forwarded ones to finish, so a switch doesn't cut off running queries.
+// Idle connections, such as those kept open by connection pools, are closed
+// rather than waited for. New connections stay refused until endProxyDrain.
+func DrainProxy(timeout time.Duration) {
+	proxyMu.Lock()
+	running := proxyListener != nil
+	proxyBackend = ""
+	proxyDraining = true
+	proxyMu.Unlock()
+	if !running {
+		return
+	}
+
+	deadline := time.Now().Add(timeout)
+	closed := 0
+	for atomic.LoadInt64(&proxyActive) > 0 && time.Now().Before(deadline) {
+		closed += closeIdleProxyConnections()
+		time.Sleep(100 * time.Millisecond)
+	}
+	if closed > 0 {
+		AppLogger.Log("Proxy closed %d idle connections", closed)
+	}
+	if remaining := atomic.LoadInt64(&proxyActive); remaining > 0 {
+		AppLogger.Warn("Proxy still has %d open connections after %v, stopping the server anyway", remaining, timeout)
+	} else {
+		AppLogger.Log("Proxy connections drained")
+	}
+}
+
+// endProxyDrain ends a drain once the stop is over. The next status poll or
+// start points the proxy at a server again; if the server wasn't stopped it
+// gets the proxy's connections back right away.
+func endProxyDrain(stopped bool) {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+
+	proxyDraining = false
+	if !stopped && proxyListener != nil {
+		proxyBackend = proxyBackendFor(CurrentStatus)
+	}
+}
+
+// acceptProxyConnections serves a listener until it is closed
+func acceptProxyConnections(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				AppLogger.Error("Proxy stopped accepting connections: %v", err)
+			}
+			return
+		}
+		go forwardProxyConnection(conn)
+	}
+}
+
+// forwardProxyConnection connects a client to the current backend and copies
+// data both ways until either side closes
+func forwardProxyConnection(client net.Conn) {
+	defer client.Close()
+
+	proxyMu.Lock()
+	backend := proxyBackend
+	proxyMu.Unlock()
+	if backend == "" {
+		AppLogger.Debug("Proxy refused %s: no server is ready", client.RemoteAddr())
+		return
+	}
+
+	// Count the connection before dialing, so a drain that starts meanwhile
+	// waits for it
+	atomic.AddInt64(&proxyActive, 1)
+	defer atomic.AddInt64(&proxyActive, -1)
+
+	server, err := net.DialTimeout("tcp", backend, time.Duration(AppConfig.ConnectionTimeoutSecs)*time.Second)
+	if err != nil {
+		AppLogger.Debug("Proxy could not reach %s: %v", backend, err)
+		return
+	}
+	defer server.Close()
+
+	conn := &proxyConn{client: client, server: server}
+	conn.touch(false)
+	proxyConnsMu.Lock()
+	proxyConns[conn] = struct{}{}
+	proxyConnsMu.Unlock()
+	defer func() {
+		proxyConnsMu.Lock()
+		delete(proxyConns, conn)
+		proxyConnsMu.Unlock()
+	}()
+
+	pipeConnections(activityConn{client, conn, true}, activityConn{server, conn, false})
+}
+
+// proxyConn is a forwarded connection. Client and server take turns in the
+// MySQL protocol, so a connection whose server spoke last and has been quiet
+// since is idle, while one whose client spoke last waits for a query.
+type proxyConn struct {
+	client, server net.Conn
+	lastActivity   int64 // Unix nanoseconds of the last data either way
+	clientSpoke    int32 // 1 if the last data came from the client
+}
+
+// touch records data coming from the client or the server
+func (c *proxyConn) touch(fromClient bool) {
+	spoke := int32(0)
+	if fromClient {
+		spoke = 1
+	}
+	atomic.StoreInt32(&c.clientSpoke, spoke)
+	atomic.StoreInt64(&c.lastActivity, time.Now().UnixNano())
+}
+
+// idle reports whether the connection waits for the client's next command
+func (c *proxyConn) idle() bool {
+	if atomic.LoadInt32(&c.clientSpoke) == 1 {
+		return false
+	}
+	return time.Since(time.Unix(0, atomic.LoadInt64(&c.lastActivity))) >= proxyIdleAfter
+}
+
+// activityConn records the data read from one side of a proxyConn
+type activityConn struct {
+	net.Conn
+	conn       *proxyConn
+	fromClient bool
+}
+
+func (c activityConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.conn.touch(c.fromClient)
+	}
+	return n, err
+}
+
+// closeIdleProxyConnections closes the forwarded connections that are idle
+// and returns how many it closed
+func closeIdleProxyConnections() int {
+	proxyConnsMu.Lock()
+	defer proxyConnsMu.Unlock()
+
+	closed := 0
+	for conn := range proxyConns {
+		if conn.idle() {
+			conn.client.Close()
+			conn.server.Close()
+			delete(proxyConns, conn)
+			closed++
+		}
+	}
+	return closed
+}
+
+// pipeConnections copies data both ways until either side closes. The caller
+// closes both connections afterwards, which unblocks the other copy.
+func pipeConnections(client, server net.Conn) {
+	done := make(chan struct{}, 2)
+	copyAndSignal := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go copyAndSignal(server, client)
+	go copyAndSignal(client, server)
+	<-done
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ResetResult describes a configuration reset to a freshly initialized data directory
+type ResetResult struct {
+	ConfigName string
+	DataDir    string
+	TrashDir   string // Where the old data directory was moved; empty when it was deleted
+}
+
+// IsProtectedConfig reports whether a configuration is marked protected in its
+// [dbswitcher] group, which refuses resets:
+//
+//	protected = yes
+func IsProtectedConfig(cfg MariaDBConfig) bool {
+	return isEnabledOption(cfg.Options["protected"])
+}
+
+// GetTrashDir returns where reset moves a data directory's old contents: a
+// dbswitcher-trash directory next to it, so the move is a rename on the same
+// filesystem
+func GetTrashDir(dataDir string) string {
+	return filepath.Join(filepath.Dir(dataDir), "dbswitcher-trash")
+}
+
+// ResetConfig throws away a configuration's data directory and starts it again,
+// which initializes a new one with the configuration's init-* options and seed
+// files. The old data directory is moved to the trash directory, or deleted if
+// deleteData is set. progress receives a line per step and may be nil.
+func ResetConfig(cfg MariaDBConfig, deleteData bool, progress func(string)) (*ResetResult, error) {
+	if progress == nil {
+		progress = func(string) {}
+	}
+	if IsProtectedConfig(cfg) {
+		return nil, fmt.Errorf("'%s' is protected - remove 'protected' from its [dbswitcher] group to reset it", cfg.Name)
+	}
+	if IsContainerConfig(cfg) {
+		return nil, fmt.Errorf("container configurations can't be reset - remove the container's volume instead")
+	}
+	// Never fall back to the packaged data directory
+	if cfg.DataDir == "" {
+		return nil, fmt.Errorf("'%s' doesn't set a datadir", cfg.Name)
+	}
+
+	dataDir := ResolveDataDir(cfg)
+	if empty, _ := IsDirEmpty(dataDir); PathExists(dataDir) && !empty && !PathExists(filepath.Join(dataDir, "mysql")) {
+		return nil, fmt.Errorf("%s doesn't look like a data directory (no mysql schema), refusing to reset it", dataDir)
+	}
+
+	result := &ResetResult{ConfigName: cfg.Name, DataDir: dataDir}
+	AppLogger.Info("Resetting '%s' (data directory %s)", cfg.Name, dataDir)
+
+	if IsMariaDBRunning() {
+		if !IsConfigActive(cfg, GetMariaDBStatus()) {
+			return nil, fmt.Errorf("another server is running - stop it before resetting '%s'", cfg.Name)
+		}
+		progress("Stopping the server...")
+		if err := StopMySQLWithCredentials(GetCredentialsForConfig(cfg)); err != nil {
+			return nil, err
+		}
+	}
+
+	if PathExists(dataDir) {
+		if deleteData {
+			progress(fmt.Sprintf("Deleting %s...", dataDir))
+			if err := os.RemoveAll(dataDir); err != nil {
+				return nil, fmt.Errorf("failed to delete data directory: %v", err)
+			}
+		} else {
+			trashDir := GetTrashDir(dataDir)
+			if err := os.MkdirAll(trashDir, 0700); err != nil {
+				return nil, fmt.Errorf("failed to create trash directory: %v", err)
+			}
+			result.TrashDir = filepath.Join(trashDir, fmt.Sprintf("%s-%s", filepath.Base(dataDir), time.Now().Format("20060102-150405")))
+			progress(fmt.Sprintf("Moving %s to %s...", dataDir, result.TrashDir))
+			if err := os.Rename(dataDir, result.TrashDir); err != nil {
+				return nil, fmt.Errorf("failed to move data directory to the trash: %v", err)
+			}
+		}
+	}
+
+	// Credentials generated for the old data directory no longer apply
+	if err := DeleteConfigCredentials(cfg.Name); err != nil {
+		AppLogger.Warn("Failed to remove the old credentials of %s: %v", cfg.Name, err)
+	}
+
+	progress(fmt.Sprintf("Initializing and starting '%s'...", cfg.Name))
+	if err := StartMariaDBWithConfig(cfg.Path); err != nil {
+		return result, fmt.Errorf("failed to start '%s' with a new data directory: %v", cfg.Name, err)
+	}
+
+	AppLogger.Info("Reset '%s' to a new data directory", cfg.Name)
+	ShowNotification("Reset Complete", fmt.Sprintf("'%s' was reset and started", cfg.Name), SuccessNotification)
+	return result, nil
+}
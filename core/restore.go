@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// mountPollInterval is how often the data directory is checked while waiting for its filesystem
+const mountPollInterval = 2 * time.Second
+
+// GetRestoreConfig returns the configuration to start on login: the designated
+// default if set, otherwise the last used one. It returns nil if there is none.
+func GetRestoreConfig() *MariaDBConfig {
+	if AppConfig.DefaultConfig != "" {
+		if cfg := FindConfigByName(AppConfig.DefaultConfig); cfg != nil {
+			return cfg
+		}
+		AppLogger.Warn("Default configuration '%s' not found, falling back to last used", AppConfig.DefaultConfig)
+	}
+
+	if AppConfig.LastUsedConfig != "" && PathExists(AppConfig.LastUsedConfig) {
+		cfg := ConfigForPath(AppConfig.LastUsedConfig)
+		return &cfg
+	}
+	return nil
+}
+
+// RestoreConfigOnLogin starts the default or last used configuration if enabled
+// in settings. It waits for the data directory's filesystem to be mounted first
+// and notifies when the configuration cannot be started.
+func RestoreConfigOnLogin() error {
+	if !AppConfig.RestoreOnLogin {
+		return nil
+	}
+
+	cfg := GetRestoreConfig()
+	if cfg == nil {
+		AppLogger.Info("No configuration to restore on login")
+		return nil
+	}
+
+	if IsMariaDBRunning() {
+		AppLogger.Info("MariaDB is already running, not restoring '%s'", cfg.Name)
+		return nil
+	}
+
+	AppLogger.Info("Restoring configuration '%s' on login", cfg.Name)
+
+	timeout := time.Duration(AppConfig.MountWaitSecs) * time.Second
+	if err := WaitForDataDir(*cfg, timeout); err != nil {
+		NotifyMariaDBError(fmt.Sprintf("Could not restore '%s': %v", cfg.Name, err))
+		NotifySwitchFailed(cfg.Name, err)
+		return err
+	}
+
+	if err := StartMariaDBWithConfig(cfg.Path); err != nil {
+		NotifyMariaDBError(fmt.Sprintf("Could not restore '%s': %v", cfg.Name, err))
+		NotifySwitchFailed(cfg.Name, err)
+		return err
+	}
+	return nil
+}
+
+// WaitForDataDir waits until a configuration's data directory exists and is
+// not empty, which is how an unmounted drive or network share shows up. Starting
+// earlier would initialize a fresh, empty data directory on the mount point and
+// replace the credentials of the real one. An empty data directory that is
+// itself a mounted filesystem is ready: it is a new drive to initialize.
+func WaitForDataDir(cfg MariaDBConfig, timeout time.Duration) error {
+	dataDir := ResolveDataDir(cfg)
+	if dataDir == "" {
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if PathExists(dataDir) {
+			if empty, err := IsDirEmpty(dataDir); err == nil && (!empty || isMountPoint(dataDir)) {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			if empty, _ := IsDirEmpty(dataDir); empty {
+				return fmt.Errorf("data directory %s is still empty after %s (drive not mounted? a new configuration must be started by hand first)", dataDir, timeout)
+			}
+			return fmt.Errorf("data directory %s not available after %s (drive not mounted?)", dataDir, timeout)
+		}
+
+		AppLogger.Debug("Waiting for data directory %s to be mounted...", dataDir)
+		time.Sleep(mountPollInterval)
+	}
+}
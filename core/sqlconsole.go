@@ -0,0 +1,321 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// queryMaxRows is the number of rows kept per result set; the rest is dropped
+	queryMaxRows = 10000
+
+	// queryHistorySize is the number of queries remembered per configuration
+	queryHistorySize = 100
+)
+
+// QueryResult is a result set of a query run by RunQuery
+type QueryResult struct {
+	Statement string
+	Columns   []string    // Empty for a result set without rows
+	Rows      [][]*string // nil values are NULL
+	Truncated bool        // More than queryMaxRows rows; the rest was dropped
+}
+
+// connectionIDColumn names the column of the statement RunQuery runs first to
+// learn the connection it can kill
+const connectionIDColumn = "dbswitcher_connection_id"
+
+// RunQuery runs SQL statements on a configuration's server with the client of
+// its installation and its admin credentials, and returns their result sets.
+// It gives up after ConnectionTimeoutSecs or when ctx is cancelled, killing
+// the running statement on the server.
+func RunQuery(parent context.Context, cfg MariaDBConfig, database, query string) ([]QueryResult, error) {
+	cmd, cleanup, err := ClientCommand(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	timeout := time.Duration(AppConfig.ConnectionTimeoutSecs) * time.Second
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	cmd.Args = append(cmd.Args, "--xml", fmt.Sprintf("--connect-timeout=%d", AppConfig.ConnectionTimeoutSecs))
+	if database != "" {
+		cmd.Args = append(cmd.Args, "--database="+database)
+	}
+	// On the same line as the query, so error line numbers still match it
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("SELECT CONNECTION_ID() AS %s; %s", connectionIDColumn, query))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// Don't hang on output held open by anything the client started
+	cmd.WaitDelay = time.Second
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %v", filepath.Base(cmd.Path), err)
+	}
+
+	var (
+		connectionID string
+		idMu         sync.Mutex
+	)
+	type decoded struct {
+		results []QueryResult
+		err     error
+	}
+	done := make(chan decoded, 1)
+	go func() {
+		results, err := decodeQueryResults(stdout, func(id string) {
+			idMu.Lock()
+			connectionID = id
+			idMu.Unlock()
+		})
+		done <- decoded{results, err}
+	}()
+
+	select {
+	case out := <-done:
+		waitErr := cmd.Wait()
+		if message := strings.TrimSpace(stderr.String()); waitErr != nil && message != "" {
+			return out.results, errors.New(message)
+		} else if waitErr != nil {
+			return out.results, fmt.Errorf("%s failed: %v", filepath.Base(cmd.Path), waitErr)
+		}
+		return out.results, out.err
+	case <-ctx.Done():
+		idMu.Lock()
+		id := connectionID
+		idMu.Unlock()
+		// Killing the client alone can leave the statement running on the server
+		if id != "" {
+			if _, err := ExecMySQLQueryRows("KILL QUERY "+id, GetCredentialsForConfig(cfg)); err != nil {
+				AppLogger.Warn("Failed to kill query %s on %s: %v", id, cfg.Name, err)
+			}
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+		<-done
+		if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("query timed out after %v", timeout)
+		}
+		return nil, fmt.Errorf("query cancelled")
+	}
+}
+
+// decodeQueryResults reads the client's --xml output. The result set of the
+// connection id query goes to onConnectionID instead of the results.
+func decodeQueryResults(r io.Reader, onConnectionID func(string)) ([]QueryResult, error) {
+	var (
+		results []QueryResult
+		current *QueryResult
+		row     []*string
+		field   *string
+		text    strings.Builder
+	)
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return results, fmt.Errorf("failed to read query results: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "resultset":
+				current = &QueryResult{Statement: xmlAttr(t, "statement")}
+			case "row":
+				row = nil
+			case "field":
+				text.Reset()
+				field = new(string)
+				if xmlAttr(t, "nil") == "true" {
+					field = nil
+				}
+				if current != nil && len(current.Rows) == 0 && len(row) == len(current.Columns) {
+					current.Columns = append(current.Columns, xmlAttr(t, "name"))
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "field":
+				if field != nil {
+					*field = text.String()
+				}
+				row = append(row, field)
+			case "row":
+				if current == nil {
+					continue
+				}
+				if len(current.Rows) >= queryMaxRows {
+					current.Truncated = true
+				} else {
+					current.Rows = append(current.Rows, row)
+				}
+			case "resultset":
+				if current == nil {
+					continue
+				}
+				if len(current.Columns) == 1 && current.Columns[0] == connectionIDColumn && results == nil {
+					if len(current.Rows) == 1 && current.Rows[0][0] != nil {
+						onConnectionID(*current.Rows[0][0])
+					}
+				} else {
+					results = append(results, *current)
+				}
+				current = nil
+			}
+		}
+	}
+}
+
+// xmlAttr returns the value of an attribute by local name
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// WriteQueryResultCSV writes a result set as CSV with a header row. NULL is
+// written as an empty field.
+func WriteQueryResultCSV(w io.Writer, result QueryResult) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(result.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(result.Columns))
+	for _, row := range result.Rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) && row[i] != nil {
+				record[i] = *row[i]
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteQueryResultJSON writes a result set as a JSON array with an object per
+// row. All values are strings, except NULL.
+func WriteQueryResultJSON(w io.Writer, result QueryResult) error {
+	// Built by hand to keep the column order
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	b.WriteByte('[')
+	for r, row := range result.Rows {
+		if r > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('{')
+		for i, column := range result.Columns {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			var value *string
+			if i < len(row) {
+				value = row[i]
+			}
+			if err := encoder.Encode(column); err != nil {
+				return err
+			}
+			b.WriteByte(':')
+			if err := encoder.Encode(value); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(']')
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, b.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	_, err := indented.WriteTo(w)
+	return err
+}
+
+var queryHistoryMu sync.Mutex
+
+// getQueryHistoryPath returns the file holding the query history of all configurations
+func getQueryHistoryPath() string {
+	return filepath.Join(GetAppDataDir(), "query_history.json")
+}
+
+// loadQueryHistory reads the query history, keyed by configuration name. The
+// caller must hold queryHistoryMu.
+func loadQueryHistory() map[string][]string {
+	history := map[string][]string{}
+	data, err := os.ReadFile(getQueryHistoryPath())
+	if err != nil {
+		return history
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		AppLogger.Warn("Ignoring unreadable query history: %v", err)
+		return map[string][]string{}
+	}
+	return history
+}
+
+// GetQueryHistory returns the queries run on a configuration, most recent first
+func GetQueryHistory(configName string) []string {
+	queryHistoryMu.Lock()
+	defer queryHistoryMu.Unlock()
+	return loadQueryHistory()[configName]
+}
+
+// AddQueryHistory remembers a query run on a configuration. Running a query
+// again moves it to the top.
+func AddQueryHistory(configName, query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	queryHistoryMu.Lock()
+	defer queryHistoryMu.Unlock()
+
+	history := loadQueryHistory()
+	queries := []string{query}
+	for _, previous := range history[configName] {
+		if previous != query && len(queries) < queryHistorySize {
+			queries = append(queries, previous)
+		}
+	}
+	history[configName] = queries
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Queries may contain data, so keep the file private
+	return os.WriteFile(getQueryHistoryPath(), data, 0600)
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// ClientCommand returns the command-line client (mariadb or mysql) of a
+// configuration's installation, logged in with the configuration's admin
+// credentials. The credentials go into a temporary option file only the
+// current user can read, never onto the command line; cleanup removes it and
+// must run after the client exits.
+func ClientCommand(cfg MariaDBConfig) (cmd *exec.Cmd, cleanup func(), err error) {
+	if IsContainerConfig(cfg) {
+		return nil, nil, fmt.Errorf("container configurations have their client in the container")
+	}
+	binDir := BinDirForConfig(cfg)
+	clientPath := GetBinaryFlavor(binDir).ClientPath(binDir)
+	if !PathExists(clientPath) {
+		return nil, nil, fmt.Errorf("client not found at %s", clientPath)
+	}
+
+	optionFile, err := writeClientOptionFile(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { removeOptionFile(optionFile) }
+
+	// --defaults-extra-file must come first
+	cmd = exec.Command(clientPath, "--defaults-extra-file="+optionFile, "--prompt="+cfg.Name+" [\\d]> ")
+	return cmd, cleanup, nil
+}
+
+// writeClientOptionFile writes a configuration's connection details with its
+// admin credentials to a new temporary file with 0600 permissions
+func writeClientOptionFile(cfg MariaDBConfig) (string, error) {
+	details := GetConnectionDetails(cfg)
+	creds := GetCredentialsForConfig(cfg)
+	details.User, details.Password = creds.Username, creds.Password
+	return writeOptionFile(details)
+}
+
+// writeOptionFile writes connection details, including the password, as a
+// [client] option file to a new temporary file with 0600 permissions
+func writeOptionFile(details ConnectionDetails) (string, error) {
+	content, err := details.Format(FormatOptionFile, true)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", "dbswitcher-client-*.cnf")
+	if err != nil {
+		return "", fmt.Errorf("failed to create client option file: %v", err)
+	}
+	// CreateTemp uses 0600 already; make sure, since the file holds a password
+	if err := f.Chmod(0600); err != nil && runtime.GOOS != "windows" {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to restrict client option file: %v", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write client option file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// removeOptionFile removes a temporary option file
+func removeOptionFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		AppLogger.Warn("Failed to remove client option file %s: %v", path, err)
+	}
+}
@@ -0,0 +1,322 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// supervisorInterval is how often the supervisor checks the instance it started
+const supervisorInterval = 5 * time.Second
+
+// RestartPolicy controls automatic restarts after a crash. It is read from the
+// [dbswitcher] group of a config file:
+//
+//	auto-restart = true          # restart after an unexpected exit (default false)
+//	restart-backoff = 5          # first delay in seconds, doubled after each crash
+//	restart-backoff-max = 300    # upper bound for the delay in seconds
+//	restart-max = 5              # give up after this many crashes ...
+//	restart-window = 600         # ... within this many seconds
+type RestartPolicy struct {
+	AutoRestart bool
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	MaxCrashes  int
+	Window      time.Duration
+}
+
+// supervisedInstance tracks the server started by this process
+type supervisedInstance struct {
+	config     MariaDBConfig
+	startedAt  time.Time
+	crashes    []time.Time
+	exited     <-chan struct{} // Closed when a server started as our child exits; nil otherwise
+	stopping   bool            // A stop was requested, so an exit is expected
+	restarting bool            // A restart is scheduled or in progress
+	misses     int             // Consecutive checks that found no server
+}
+
+var (
+	supervised     *supervisedInstance
+	supervisorMu   sync.Mutex
+	supervisorOnce sync.Once
+)
+
+// GetRestartPolicy reads the restart policy of a configuration
+func GetRestartPolicy(cfg MariaDBConfig) RestartPolicy {
+	policy := RestartPolicy{
+		AutoRestart: strings.EqualFold(cfg.Options["auto-restart"], "true") ||
+			cfg.Options["auto-restart"] == "1" || strings.EqualFold(cfg.Options["auto-restart"], "on"),
+		Backoff:    optionSeconds(cfg, "restart-backoff", 5),
+		MaxBackoff: optionSeconds(cfg, "restart-backoff-max", 300),
+		MaxCrashes: 5,
+		Window:     optionSeconds(cfg, "restart-window", 600),
+	}
+	if value, ok := cfg.Options["restart-max"]; ok {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			policy.MaxCrashes = n
+		} else {
+			AppLogger.Warn("Ignoring invalid restart-max '%s' in %s", value, cfg.Name)
+		}
+	}
+	return policy
+}
+
+// optionSeconds reads a positive number of seconds from the [dbswitcher] group
+func optionSeconds(cfg MariaDBConfig, key string, defaultSecs int) time.Duration {
+	if value, ok := cfg.Options[key]; ok {
+		if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		AppLogger.Warn("Ignoring invalid %s '%s' in %s", key, value, cfg.Name)
+	}
+	return time.Duration(defaultSecs) * time.Second
+}
+
+// StartSupervisor starts watching instances started by this process.
+// It is meant for long-running processes (GUI and tray); calling it again is a no-op.
+func StartSupervisor() {
+	supervisorOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(supervisorInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				checkSupervisedInstance()
+			}
+		}()
+		watchContainers()
+		AppLogger.Info("Instance supervisor started")
+	})
+}
+
+// superviseInstance records that this process started a server with a configuration.
+// exited is closed when the server exits, if it was started as a child of this
+// process. Crash history is kept when the same configuration is restarted.
+func superviseInstance(cfg MariaDBConfig, exited <-chan struct{}) {
+	supervisorMu.Lock()
+	defer supervisorMu.Unlock()
+
+	if supervised != nil && filepath.Clean(supervised.config.Path) == filepath.Clean(cfg.Path) {
+		supervised.config = cfg
+		supervised.startedAt = time.Now()
+		supervised.exited = exited
+		supervised.stopping = false
+		supervised.restarting = false
+		supervised.misses = 0
+		return
+	}
+	supervised = &supervisedInstance{config: cfg, startedAt: time.Now(), exited: exited}
+}
+
+// expectInstanceStop marks the supervised server as being stopped on purpose,
+// or clears the mark again when the stop failed
+func expectInstanceStop(stopping bool) {
+	supervisorMu.Lock()
+	defer supervisorMu.Unlock()
+
+	if supervised != nil {
+		supervised.stopping = stopping
+	}
+}
+
+// expectConfigStop is expectInstanceStop for stops of a particular
+// configuration, which only concern the supervisor if it is the supervised one
+func expectConfigStop(cfg MariaDBConfig, stopping bool) {
+	supervisorMu.Lock()
+	defer supervisorMu.Unlock()
+
+	if supervised != nil && filepath.Clean(supervised.config.Path) == filepath.Clean(cfg.Path) {
+		supervised.stopping = stopping
+	}
+}
+
+// isInstanceRunning checks the supervised server itself rather than any
+// server, so other instances (ephemeral ones, containers) can't hide its exit
+func isInstanceRunning(inst *supervisedInstance) bool {
+	cfg := inst.config
+	switch {
+	case IsContainerConfig(cfg):
+		state, err := ContainerStatus(ContainerName(cfg))
+		return err == nil && state.Running
+	case inst.exited != nil:
+		select {
+		case <-inst.exited:
+			return false
+		default:
+			return true
+		}
+	case UseSystemdServices():
+		state, err := GetConfigServiceState(cfg)
+		return err == nil && state.IsActive()
+	default:
+		return IsPortListening(cfg.Port)
+	}
+}
+
+// wasStoppedCleanly reports whether the service manager or container runtime
+// saw the supervised server stop without an error, i.e. it was stopped from
+// outside DBSwitcher (systemctl stop, docker stop)
+func wasStoppedCleanly(inst *supervisedInstance) bool {
+	cfg := inst.config
+	switch {
+	case IsContainerConfig(cfg):
+		state, err := ContainerStatus(ContainerName(cfg))
+		return err == nil && !state.Running && state.ExitCode == 0
+	case inst.exited == nil && UseSystemdServices():
+		state, err := GetConfigServiceState(cfg)
+		return err == nil && state.ActiveState == "inactive"
+	}
+	return false
+}
+
+// checkSupervisedInstance detects an unexpected exit of the supervised server
+func checkSupervisedInstance() {
+	supervisorMu.Lock()
+	inst := supervised
+	if inst == nil || inst.restarting {
+		supervisorMu.Unlock()
+		return
+	}
+	supervisorMu.Unlock()
+
+	running := isInstanceRunning(inst)
+
+	supervisorMu.Lock()
+	defer supervisorMu.Unlock()
+
+	if supervised != inst || inst.restarting {
+		return // Changed while we were checking
+	}
+	if running {
+		inst.misses = 0
+		return
+	}
+	if inst.stopping {
+		AppLogger.Debug("Supervisor: %s stopped as requested", inst.config.Name)
+		supervised = nil
+		return
+	}
+
+	// Require two consecutive misses so a slow process listing isn't taken for a crash
+	inst.misses++
+	if inst.misses < 2 {
+		return
+	}
+
+	handleInstanceExit(inst)
+}
+
+// handleInstanceExit reports an unexpected exit and schedules a restart if the
+// policy allows it. The caller must hold supervisorMu.
+func handleInstanceExit(inst *supervisedInstance) {
+	cfg := inst.config
+	logPath := ErrorLogPath(cfg)
+	tail := TailFile(logPath, 20)
+
+	// A clean shutdown by someone else (e.g. mysqladmin from a terminal) is not a crash
+	if wasStoppedCleanly(inst) || (strings.Contains(tail, "Shutdown complete") && !strings.Contains(tail, "[ERROR]")) {
+		AppLogger.Info("Supervisor: %s was shut down outside DBSwitcher", cfg.Name)
+		supervised = nil
+		return
+	}
+
+	AppLogger.Error("Supervisor: %s exited unexpectedly after %s", cfg.Name,
+		time.Since(inst.startedAt).Round(time.Second))
+	if tail != "" {
+		AppLogger.Error("Last lines of %s:\n%s", logPath, tail)
+	}
+
+	details := ""
+	if tail != "" {
+		details = lastLine(tail)
+	}
+	go NotifyMariaDBCrashed(cfg.Name, details)
+
+	policy := GetRestartPolicy(cfg)
+	if !policy.AutoRestart {
+		supervised = nil
+		return
+	}
+
+	// Count crashes within the window to detect a crash loop
+	now := time.Now()
+	inst.crashes = append(inst.crashes, now)
+	recent := inst.crashes[:0]
+	for _, t := range inst.crashes {
+		if now.Sub(t) <= policy.Window {
+			recent = append(recent, t)
+		}
+	}
+	inst.crashes = recent
+
+	if len(recent) > policy.MaxCrashes {
+		AppLogger.Error("Supervisor: %s crashed %d times within %s, giving up", cfg.Name, len(recent), policy.Window)
+		go NotifyMariaDBError(fmt.Sprintf("'%s' is crash-looping (%d crashes within %s); automatic restart disabled",
+			cfg.Name, len(recent), policy.Window))
+		supervised = nil
+		return
+	}
+
+	backoff := policy.Backoff
+	for i := 1; i < len(recent) && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+
+	inst.restarting = true
+	inst.misses = 0
+	AppLogger.Info("Supervisor: restarting %s in %s (attempt %d)", cfg.Name, backoff, len(recent))
+
+	go func() {
+		time.Sleep(backoff)
+
+		// A stop or another start may have happened in the meantime
+		supervisorMu.Lock()
+		current := supervised == inst
+		supervisorMu.Unlock()
+		if !current {
+			return
+		}
+
+		err := StartMariaDBWithConfig(cfg.Path)
+
+		supervisorMu.Lock()
+		defer supervisorMu.Unlock()
+		if err != nil {
+			// The next check sees the server down again and counts it as another crash
+			AppLogger.Error("Supervisor: restart of %s failed: %v", cfg.Name, err)
+			inst.restarting = false
+			return
+		}
+		AppLogger.Info("Supervisor: %s restarted", cfg.Name)
+	}()
+}
+
+// ErrorLogPath returns where a configuration's server writes its error log:
+// the configured log-error if it exists (relative paths are inside the data
+// directory), otherwise the console log captured at startup. For containers and
+// services the console log is refreshed from the container's output or the
+// journal first.
+func ErrorLogPath(cfg MariaDBConfig) string {
+	if IsContainerConfig(cfg) {
+		return RefreshContainerLog(cfg)
+	}
+	if cfg.LogError != "" {
+		logPath := cfg.LogError
+		if !filepath.IsAbs(logPath) {
+			logPath = filepath.Join(ResolveDataDir(cfg), logPath)
+		}
+		if PathExists(logPath) {
+			return logPath
+		}
+	}
+	if UseSystemdServices() {
+		return RefreshServiceLog(cfg)
+	}
+	return GetConsoleLogPath(cfg.Name)
+}
@@ -0,0 +1,431 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Service scopes and modes for the systemd backend
+const (
+	ServiceScopeUser   = "user"   // Units in ~/.config/systemd/user, managed with systemctl --user
+	ServiceScopeSystem = "system" // Units in /etc/systemd/system, managed with (sudo) systemctl
+
+	ServiceModeUnit   = "unit"   // One dbswitcher-<config>.service unit per configuration
+	ServiceModeDropIn = "dropin" // A drop-in that points the distro service at the configuration
+)
+
+// dropInFileName is the drop-in written for the distro service in drop-in mode
+const dropInFileName = "dbswitcher.conf"
+
+// ServiceState is the state of a systemd unit as reported by systemctl show
+type ServiceState struct {
+	Unit          string
+	LoadState     string // loaded, not-found, ...
+	ActiveState   string // active, inactive, failed, activating, ...
+	SubState      string // running, dead, exited, ...
+	UnitFileState string // enabled, disabled, static, ...
+	MainPID       int
+}
+
+// IsActive reports whether the unit is running or about to be
+func (s ServiceState) IsActive() bool {
+	return s.ActiveState == "active" || s.ActiveState == "activating" || s.ActiveState == "reloading"
+}
+
+var (
+	systemdAvailable     bool
+	systemdAvailableOnce sync.Once
+)
+
+// IsSystemdAvailable reports whether this is a Linux system with systemctl
+func IsSystemdAvailable() bool {
+	systemdAvailableOnce.Do(func() {
+		if runtime.GOOS != "linux" {
+			return
+		}
+		_, err := exec.LookPath("systemctl")
+		systemdAvailable = err == nil && PathExists("/run/systemd/system")
+	})
+	return systemdAvailable
+}
+
+// UseSystemdServices reports whether configurations are started as systemd
+// services. It is a separate opt-in setting: UseServiceControl is set by
+// auto-detection and only says that a distro service exists.
+func UseSystemdServices() bool {
+	return AppConfig.SystemdServices && IsSystemdAvailable()
+}
+
+// isSystemScope reports whether units are managed by the system instance of systemd
+func isSystemScope() bool {
+	return AppConfig.ServiceScope == ServiceScopeSystem || AppConfig.ServiceMode == ServiceModeDropIn
+}
+
+// GetDistroServiceName returns the distribution's MariaDB service (e.g. mariadb)
+func GetDistroServiceName() string {
+	name := AppConfig.ServiceNames["linux"]
+	if name == "" {
+		name = "mariadb"
+	}
+	return name
+}
+
+// ServiceUnitName returns the unit name managed by DBSwitcher for a configuration.
+// In drop-in mode this is the distro service itself.
+func ServiceUnitName(cfg MariaDBConfig) string {
+	if AppConfig.ServiceMode == ServiceModeDropIn {
+		return GetDistroServiceName() + ".service"
+	}
+
+	return "dbswitcher-" + sanitizeName(cfg.Name) + ".service"
+}
+
+// sanitizeName lowercases a configuration name and replaces everything but
+// letters, digits, '-' and '_' so it can be used in unit and container names
+func sanitizeName(name string) string {
+	var sanitized strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			sanitized.WriteRune(r)
+		} else {
+			sanitized.WriteRune('-')
+		}
+	}
+	return sanitized.String()
+}
+
+// serviceFilePath returns where the unit or drop-in for a configuration is written
+func serviceFilePath(cfg MariaDBConfig) string {
+	if AppConfig.ServiceMode == ServiceModeDropIn {
+		return filepath.Join("/etc/systemd/system", GetDistroServiceName()+".service.d", dropInFileName)
+	}
+	if isSystemScope() {
+		return filepath.Join("/etc/systemd/system", ServiceUnitName(cfg))
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, _ := os.UserHomeDir()
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "systemd", "user", ServiceUnitName(cfg))
+}
+
+// GenerateServiceUnit returns the unit file (or drop-in in drop-in mode) that
+// runs mysqld with the configuration's --defaults-file. System units run the
+// server as the user running DBSwitcher: the configuration file is theirs to
+// edit, and options such as init-file or plugin-load would otherwise run
+// their code as root or the distro service's user.
+func GenerateServiceUnit(cfg MariaDBConfig, mysqldPath string) (string, error) {
+	absConfigFile, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return "", fmt.Errorf("cannot get absolute path for config: %v", err)
+	}
+	execStart := fmt.Sprintf("%s --defaults-file=%s", systemdQuote(mysqldPath), systemdQuote(absConfigFile))
+	runAs := ""
+	if isSystemScope() {
+		userName, groupName := currentUserName(), currentGroupName()
+		if userName == "" || groupName == "" {
+			return "", fmt.Errorf("cannot determine the user and group to run the service as")
+		}
+		runAs = fmt.Sprintf("User=%s\nGroup=%s\n", userName, groupName)
+	}
+
+	var unit strings.Builder
+	fmt.Fprintf(&unit, "# Generated by DBSwitcher for configuration '%s' (%s).\n", cfg.Name, absConfigFile)
+	unit.WriteString("# It is rewritten whenever the configuration is started as a service.\n")
+
+	if AppConfig.ServiceMode == ServiceModeDropIn {
+		unit.WriteString("[Service]\n")
+		unit.WriteString("ExecStart=\n")
+		fmt.Fprintf(&unit, "ExecStart=%s\n", execStart)
+		unit.WriteString(runAs)
+		return unit.String(), nil
+	}
+
+	timeout := AppConfig.ProcessTimeoutSecs
+	if timeout <= 0 {
+		timeout = 30
+	}
+	wantedBy := "default.target"
+	if isSystemScope() {
+		wantedBy = "multi-user.target"
+	}
+
+	fmt.Fprintf(&unit, "[Unit]\nDescription=MariaDB (DBSwitcher: %s)\nAfter=network.target\n\n", cfg.Name)
+	unit.WriteString("[Service]\nType=simple\n")
+	fmt.Fprintf(&unit, "ExecStart=%s\n", execStart)
+	unit.WriteString(runAs)
+	if mysqldPath != "" {
+		fmt.Fprintf(&unit, "WorkingDirectory=%s\n", systemdQuote(filepath.Dir(mysqldPath)))
+	}
+	fmt.Fprintf(&unit, "TimeoutStartSec=%d\nTimeoutStopSec=300\n", timeout)
+	// Crash handling is left to the DBSwitcher supervisor
+	unit.WriteString("Restart=no\nLimitNOFILE=32768\n\n")
+	fmt.Fprintf(&unit, "[Install]\nWantedBy=%s\n", wantedBy)
+	return unit.String(), nil
+}
+
+// RefreshServiceLog copies the recent journal output of a configuration's
+// service to its console log, where error log tails are read from, and returns
+// its path. Units log to the journal so systemd never writes files as root.
+func RefreshServiceLog(cfg MariaDBConfig) string {
+	logPath := GetConsoleLogPath(cfg.Name)
+	args := []string{"-u", ServiceUnitName(cfg), "-n", strconv.Itoa(containerLogLines), "--no-pager", "-o", "cat"}
+	if !isSystemScope() {
+		args = append([]string{"--user"}, args...)
+	}
+	output, err := exec.Command("journalctl", args...).Output()
+	if err != nil {
+		AppLogger.Debug("Could not read the journal of %s: %v", ServiceUnitName(cfg), err)
+		return logPath
+	}
+	if err := os.WriteFile(logPath, output, 0644); err != nil {
+		AppLogger.Warn("Failed to write %s: %v", logPath, err)
+	}
+	return logPath
+}
+
+// systemdQuote quotes a value for an ExecStart= line
+func systemdQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "%", "%%")
+	return `"` + value + `"`
+}
+
+// InstallService writes the unit or drop-in for a configuration and reloads systemd
+func InstallService(cfg MariaDBConfig, mysqldPath string) error {
+	if !IsSystemdAvailable() {
+		return fmt.Errorf("systemd is not available on this system")
+	}
+
+	content, err := GenerateServiceUnit(cfg, mysqldPath)
+	if err != nil {
+		return err
+	}
+
+	path := serviceFilePath(cfg)
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == content {
+		AppLogger.Debug("Service file %s is up to date", path)
+		return nil
+	}
+
+	AppLogger.Info("Writing service file %s", path)
+	if err := writeServiceFile(path, content); err != nil {
+		return err
+	}
+	return runSystemctl("daemon-reload")
+}
+
+// UninstallService stops, disables and removes the unit or drop-in of a configuration
+func UninstallService(cfg MariaDBConfig) error {
+	unit := ServiceUnitName(cfg)
+	path := serviceFilePath(cfg)
+
+	if AppConfig.ServiceMode != ServiceModeDropIn {
+		// Stopping the unit is not a crash
+		expectConfigStop(cfg, true)
+		runSystemctl("disable", "--now", unit)
+	}
+
+	AppLogger.Info("Removing service file %s", path)
+	if isSystemScope() && !IsRoot() {
+		if err := runElevatedHelper(nil, HelperRemoveUnit, path); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", path, err)
+		}
+	} else if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %v", path, err)
+	}
+	return runSystemctl("daemon-reload")
+}
+
+// StartConfigService installs the service for a configuration and starts it
+func StartConfigService(cfg MariaDBConfig, mysqldPath string) error {
+	if err := InstallService(cfg, mysqldPath); err != nil {
+		return err
+	}
+
+	unit := ServiceUnitName(cfg)
+	AppLogger.Info("Starting service %s", unit)
+	if err := runSystemctl("start", unit); err != nil {
+		return fmt.Errorf("failed to start %s: %v", unit, err)
+	}
+	return nil
+}
+
+// StopService stops a systemd unit in the configured scope
+func StopService(unit string) error {
+	AppLogger.Info("Stopping service %s", unit)
+	if err := runSystemctl("stop", unit); err != nil {
+		return fmt.Errorf("failed to stop %s: %v", unit, err)
+	}
+	return nil
+}
+
+// EnableService enables or disables starting a configuration's service at boot (system
+// scope) or login (user scope)
+func EnableService(cfg MariaDBConfig, enable bool) error {
+	action := "disable"
+	if enable {
+		action = "enable"
+	}
+	unit := ServiceUnitName(cfg)
+	if err := runSystemctl(action, unit); err != nil {
+		return fmt.Errorf("failed to %s %s: %v", action, unit, err)
+	}
+	return nil
+}
+
+// GetServiceStates reads the state of one or more units with a single systemctl show.
+// System units are queried when system is true, user units otherwise.
+func GetServiceStates(system bool, units ...string) ([]ServiceState, error) {
+	if len(units) == 0 {
+		return nil, nil
+	}
+
+	args := []string{"show", "-p", "Id", "-p", "LoadState", "-p", "ActiveState",
+		"-p", "SubState", "-p", "UnitFileState", "-p", "MainPID"}
+	if !system {
+		args = append([]string{"--user"}, args...)
+	}
+	args = append(args, units...)
+
+	// Reading state never needs elevation
+	output, err := exec.Command("systemctl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("systemctl show failed: %v", err)
+	}
+
+	// Units are separated by blank lines
+	states := []ServiceState{}
+	for _, block := range strings.Split(strings.TrimSpace(string(output)), "\n\n") {
+		state := ServiceState{}
+		for _, line := range strings.Split(block, "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "Id":
+				state.Unit = value
+			case "LoadState":
+				state.LoadState = value
+			case "ActiveState":
+				state.ActiveState = value
+			case "SubState":
+				state.SubState = value
+			case "UnitFileState":
+				state.UnitFileState = value
+			case "MainPID":
+				state.MainPID, _ = strconv.Atoi(value)
+			}
+		}
+		if state.Unit != "" {
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
+// GetConfigServiceState returns the state of a configuration's service
+func GetConfigServiceState(cfg MariaDBConfig) (ServiceState, error) {
+	states, err := GetServiceStates(isSystemScope(), ServiceUnitName(cfg))
+	if err != nil {
+		return ServiceState{}, err
+	}
+	if len(states) == 0 {
+		return ServiceState{}, fmt.Errorf("no state reported for %s", ServiceUnitName(cfg))
+	}
+	return states[0], nil
+}
+
+// IsDistroServiceActive reports whether the distribution's MariaDB service is running
+func IsDistroServiceActive() bool {
+	if !IsSystemdAvailable() {
+		return false
+	}
+	states, err := GetServiceStates(true, GetDistroServiceName()+".service")
+	if err != nil || len(states) == 0 {
+		return false
+	}
+	return states[0].IsActive()
+}
+
+// ShouldStopDistroService reports whether the distro service is stopped before
+// starting a configuration. Drop-in mode always needs it, as it reuses that service.
+func ShouldStopDistroService() bool {
+	if !IsSystemdAvailable() {
+		return false
+	}
+	return AppConfig.StopDistroService || (UseSystemdServices() && AppConfig.ServiceMode == ServiceModeDropIn)
+}
+
+// findActiveServiceUnit returns the systemd unit running the server, if any.
+// The configuration's own unit is checked first, then the distro service.
+func findActiveServiceUnit(configFile string) string {
+	if !IsSystemdAvailable() {
+		return ""
+	}
+
+	if UseSystemdServices() && configFile != "" && AppConfig.ServiceMode != ServiceModeDropIn {
+		if cfg := FindConfigByPath(configFile); cfg != nil {
+			if state, err := GetConfigServiceState(*cfg); err == nil && state.IsActive() {
+				return state.Unit
+			}
+		}
+	}
+
+	if IsDistroServiceActive() {
+		return GetDistroServiceName() + ".service"
+	}
+	return ""
+}
+
+// runSystemctl runs systemctl in the configured scope, using the elevated
+// helper for system units when elevation is required
+func runSystemctl(args ...string) error {
+	if !isSystemScope() {
+		return runCommandWithOutput("systemctl", append([]string{"--user"}, args...)...)
+	}
+	if !IsRoot() && AppConfig.RequireElevation {
+		return runElevatedHelper(nil, HelperSystemctl, args...)
+	}
+	return runCommandWithOutput("systemctl", args...)
+}
+
+// writeServiceFile writes a unit file, through the elevated helper for system units when not root
+func writeServiceFile(path, content string) error {
+	if isSystemScope() && !IsRoot() {
+		if err := runElevatedHelper(strings.NewReader(content), HelperWriteUnit, path); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// runCommandWithOutput runs a command and includes its output in the error
+func runCommandWithOutput(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
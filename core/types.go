@@ -10,6 +10,10 @@ type Config struct {
 	AutoDetected      bool              `json:"auto_detected"`
 	UseServiceControl bool              `json:"use_service_control"`
 	RequireElevation  bool              `json:"require_elevation"`
+	SystemdServices   bool              `json:"systemd_services"`    // Start configurations as systemd services (Linux, opt-in)
+	ServiceScope      string            `json:"service_scope"`       // user or system
+	ServiceMode       string            `json:"service_mode"`        // unit or dropin
+	StopDistroService bool              `json:"stop_distro_service"` // Stop the distro service before starting a config
 	
 	// UI/Application Settings
 	AutoRefreshEnabled    bool   `json:"auto_refresh_enabled"`
@@ -19,6 +23,11 @@ type Config struct {
 	AutoStartWithSystem   bool   `json:"auto_start_with_system"`
 	LogLevel              string `json:"log_level"`
 	
+	// Login Restore Settings
+	RestoreOnLogin bool   `json:"restore_on_login"`   // Start a configuration when launched with --minimized
+	DefaultConfig  string `json:"default_config"`     // Configuration name to restore; empty means last used
+	MountWaitSecs  int    `json:"mount_wait_seconds"` // How long to wait for the data directory to appear
+	
 	// Advanced Settings
 	ProcessTimeoutSecs    int  `json:"process_timeout_seconds"`
 	MaxRetryAttempts      int  `json:"max_retry_attempts"`
@@ -26,6 +35,48 @@ type Config struct {
 	DebugMode             bool `json:"debug_mode"`
 	VerboseLogging        bool `json:"verbose_logging"`
 	BackgroundProcessing  bool `json:"background_processing"`
+
+	// Hook Settings
+	Hooks           map[string]string `json:"hooks,omitempty"` // Event name (e.g. "pre-start") to command
+	HookTimeoutSecs int               `json:"hook_timeout_seconds"`
+
+	// Notification Channels (webhook, Slack, email, command)
+	NotificationChannels []NotificationChannel `json:"notification_channels,omitempty"`
+
+	// Metrics Endpoint Settings
+	MetricsEndpointEnabled bool   `json:"metrics_endpoint_enabled"`
+	MetricsListenAddr      string `json:"metrics_listen_addr"`
+
+	// Proxy Settings
+	ProxyEnabled    bool   `json:"proxy_enabled"`
+	ProxyListenAddr string `json:"proxy_listen_addr"`
+	ProxyDrainSecs  int    `json:"proxy_drain_seconds"` // How long a stop waits for proxied connections to finish
+
+	// Container Runtime Settings
+	ContainerCLI string `json:"container_cli"` // docker, podman or a path; empty detects one
+
+	// Server Installations
+	Installations       []Installation    `json:"installations,omitempty"`        // Registered server builds
+	ConfigInstallations map[string]string `json:"config_installations,omitempty"` // Config name to pinned installation name
+}
+
+// NotificationChannel configures an external notification target.
+// The SMTP password is kept in the system keyring, not in settings.json.
+type NotificationChannel struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"` // webhook, slack, email or command
+	Enabled bool     `json:"enabled"`
+	Events  []string `json:"events,omitempty"` // Empty means all events
+
+	URL     string `json:"url,omitempty"`     // webhook and slack
+	Command string `json:"command,omitempty"` // command
+
+	SMTPHost     string   `json:"smtp_host,omitempty"`
+	SMTPPort     int      `json:"smtp_port,omitempty"`
+	SMTPUsername string   `json:"smtp_username,omitempty"`
+	From         string   `json:"from,omitempty"`
+	To           []string `json:"to,omitempty"`
+	SMTPPassword string   `json:"-"` // Overrides the keyring, e.g. when testing unsaved settings
 }
 
 // MariaDBConfig represents a detected configuration file
@@ -34,9 +85,16 @@ type MariaDBConfig struct {
 	Path        string `json:"path"`        // Full path to config file
 	DataDir     string `json:"data_dir"`    // Data directory from config
 	Port        string `json:"port"`        // Port from config
+	Socket      string `json:"socket"`      // Socket from config (Unix systems)
+	LogError    string `json:"log_error"`   // Error log from config, relative to the data directory
+	User        string `json:"user"`        // OS user the server runs as (user= in [mysqld])
+	BaseDir     string `json:"base_dir"`    // Server installation directory (basedir= in [mysqld])
 	Description string `json:"description"` // User description
 	IsActive    bool   `json:"is_active"`   // Currently running with this config
 	Exists      bool   `json:"exists"`      // File exists
+
+	// Options holds the keys of the [dbswitcher] group, lowercased (e.g. "hook-post-start")
+	Options map[string]string `json:"options,omitempty"`
 }
 
 // MariaDBStatus represents the current state
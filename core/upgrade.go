@@ -0,0 +1,332 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// UpgradeStatus compares the version a data directory was last upgraded for
+// with the server a configuration runs
+type UpgradeStatus struct {
+	DataVersion   string // Empty when the data directory doesn't record it
+	ServerVersion string
+	ServerFlavor  string
+	Needed        bool
+	Automatic     bool // The server upgrades the data directory itself at startup
+}
+
+// UpgradeResult describes a completed data directory upgrade
+type UpgradeResult struct {
+	ConfigName  string
+	FromVersion string
+	ToVersion   string
+	BackupDir   string
+	LogPath     string
+}
+
+// DataDirUpgradedVersion returns the version a data directory was last
+// initialized or upgraded for: the newer of mysql_upgrade_info (written by
+// mariadb-upgrade) and the version DBSwitcher recorded
+func DataDirUpgradedVersion(dataDir string) string {
+	best := ""
+	bestVersion := ServerVersion{}
+	consider := func(version string) {
+		if parsed, ok := ParseServerVersion(version); ok && (best == "" || parsed.Compare(bestVersion, true) > 0) {
+			best, bestVersion = version, parsed
+		}
+	}
+
+	for _, name := range []string{"mariadb_upgrade_info", "mysql_upgrade_info"} {
+		if data, err := os.ReadFile(filepath.Join(dataDir, name)); err == nil {
+			consider(strings.TrimSpace(strings.TrimRight(string(data), "\x00")))
+		}
+	}
+	_, upgraded := readDataDirServerFile(dataDir)
+	consider(upgraded)
+	return best
+}
+
+// RecordDataDirUpgrade records that a data directory is up to date for the
+// server in binDir, after initializing or upgrading it
+func RecordDataDirUpgrade(dataDir, binDir string) {
+	writeDataDirServerFile(dataDir, binDir, getBinaryInfo(binDir).version)
+}
+
+// CheckUpgradeNeeded reports whether a configuration's data directory was
+// written for an older release series than its server
+func CheckUpgradeNeeded(cfg MariaDBConfig) UpgradeStatus {
+	binDir := BinDirForConfig(cfg)
+	info := getBinaryInfo(binDir)
+	status := UpgradeStatus{
+		DataVersion:   DataDirUpgradedVersion(ResolveDataDir(cfg)),
+		ServerVersion: info.version,
+		ServerFlavor:  info.flavor,
+	}
+	if IsContainerConfig(cfg) || info.flavor == "" || status.DataVersion == "" {
+		return status
+	}
+
+	server := FlavorByName(info.flavor)
+	if FlavorByName(serverFlavor(status.DataVersion)).family != server.family {
+		return status
+	}
+	dataVersion, dataOK := ParseServerVersion(status.DataVersion)
+	serverVersion, serverOK := ParseServerVersion(info.version)
+	if !dataOK || !serverOK {
+		return status
+	}
+
+	status.Needed = serverVersion.Compare(dataVersion, false) > 0
+	// MySQL 8.0.16 and later upgrade the data directory at startup
+	status.Automatic = server.family == "mysql" && serverVersion.Compare(ServerVersion{Major: 8, Minor: 0, Patch: 16}, true) >= 0
+	return status
+}
+
+// Warning describes a pending upgrade for pre-flight output
+func (s UpgradeStatus) Warning(configName string) string {
+	if s.Automatic {
+		return fmt.Sprintf("the data directory was last upgraded for %s and will be upgraded by %s %s at startup - consider 'dbswitcher upgrade %s' to take a backup first",
+			s.DataVersion, s.ServerFlavor, s.ServerVersion, configName)
+	}
+	return fmt.Sprintf("the data directory was last upgraded for %s but the server is %s %s - run 'dbswitcher upgrade %s'",
+		s.DataVersion, s.ServerFlavor, s.ServerVersion, configName)
+}
+
+// UpgradeDataDir upgrades a configuration's data directory for its server: it
+// stops the server if the configuration is running, copies the data directory
+// next to itself as a backup, starts the configuration, runs mariadb-upgrade
+// (or mysql_upgrade) with the given credentials and records the result.
+// progress receives a line per step and may be nil.
+func UpgradeDataDir(cfg MariaDBConfig, creds MySQLCredentials, progress func(string)) (*UpgradeResult, error) {
+	if progress == nil {
+		progress = func(string) {}
+	}
+	if IsContainerConfig(cfg) {
+		return nil, fmt.Errorf("container configurations are upgraded by their image - set MARIADB_AUTO_UPGRADE=1 in the container-env-file")
+	}
+
+	dataDir := ResolveDataDir(cfg)
+	if dataDir == "" || !PathExists(dataDir) {
+		return nil, fmt.Errorf("data directory of '%s' does not exist", cfg.Name)
+	}
+
+	binDir := BinDirForConfig(cfg)
+	if err := CheckDataDirCompatibility(dataDir, binDir); err != nil {
+		return nil, err
+	}
+	status := CheckUpgradeNeeded(cfg)
+	if !status.Needed {
+		if status.DataVersion == "" {
+			return nil, fmt.Errorf("the data directory of '%s' doesn't record the version it was upgraded for; run %s manually if needed",
+				cfg.Name, strings.Join(GetBinaryFlavor(binDir).UpgradeBinaries, " or "))
+		}
+		return nil, fmt.Errorf("the data directory of '%s' is already up to date for %s %s", cfg.Name, status.ServerFlavor, status.ServerVersion)
+	}
+
+	result := &UpgradeResult{
+		ConfigName:  cfg.Name,
+		FromVersion: status.DataVersion,
+		ToVersion:   status.ServerVersion,
+		LogPath:     filepath.Join(filepath.Dir(GetConsoleLogPath(cfg.Name)), cfg.Name+".upgrade.log"),
+	}
+	AppLogger.Info("Upgrading data directory %s of '%s' from %s to %s", dataDir, cfg.Name, result.FromVersion, result.ToVersion)
+
+	// The backup is a copy of the data directory, so the server must be stopped
+	creds.Port = cfg.Port
+	if IsMariaDBRunning() {
+		if !IsConfigActive(cfg, GetMariaDBStatus()) {
+			return nil, fmt.Errorf("another server is running - stop it before upgrading '%s'", cfg.Name)
+		}
+		progress("Stopping the server...")
+		if err := StopMySQLWithCredentials(creds); err != nil {
+			return nil, err
+		}
+	}
+
+	result.BackupDir = fmt.Sprintf("%s.pre-upgrade-%s", dataDir, time.Now().Format("20060102-150405"))
+	progress(fmt.Sprintf("Backing up %s to %s...", dataDir, result.BackupDir))
+	if err := backupConfigDataDir(cfg, dataDir, result.BackupDir); err != nil {
+		return nil, fmt.Errorf("backup failed, nothing was upgraded: %v", err)
+	}
+
+	progress(fmt.Sprintf("Starting '%s' with %s %s...", cfg.Name, status.ServerFlavor, status.ServerVersion))
+	if err := StartMariaDBWithConfig(cfg.Path); err != nil {
+		return nil, fmt.Errorf("failed to start the server (backup in %s): %v", result.BackupDir, err)
+	}
+
+	if status.Automatic {
+		progress("The server upgraded the data directory at startup")
+	} else {
+		progress("Running the upgrade tool...")
+		if err := runUpgradeTool(binDir, creds, result.LogPath); err != nil {
+			return nil, fmt.Errorf("upgrade failed, see %s (backup in %s): %v", result.LogPath, result.BackupDir, err)
+		}
+	}
+
+	RecordDataDirUpgrade(dataDir, binDir)
+	AppLogger.Info("Upgraded data directory of '%s' from %s to %s, backup in %s", cfg.Name, result.FromVersion, result.ToVersion, result.BackupDir)
+	ShowNotification("Upgrade Complete",
+		fmt.Sprintf("Upgraded '%s' from %s to %s", cfg.Name, result.FromVersion, result.ToVersion), SuccessNotification)
+	return result, nil
+}
+
+// runUpgradeTool runs mariadb-upgrade or mysql_upgrade against the running
+// server and writes its output to logPath
+func runUpgradeTool(binDir string, creds MySQLCredentials, logPath string) error {
+	flavor := GetBinaryFlavor(binDir)
+	toolPath := flavor.ToolPath(binDir, flavor.UpgradeBinaries)
+	if !PathExists(toolPath) {
+		return fmt.Errorf("%s not found in %s", filepath.Base(toolPath), binDir)
+	}
+
+	// The password goes into an option file, not onto the command line
+	optionFile, err := writeOptionFile(ConnectionDetails{
+		Host:     creds.Host,
+		Port:     creds.Port,
+		User:     creds.Username,
+		Password: creds.Password,
+	})
+	if err != nil {
+		return err
+	}
+	defer removeOptionFile(optionFile)
+
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to create upgrade log: %v", err)
+	}
+	defer logFile.Close()
+
+	// --defaults-extra-file must come first
+	cmd := exec.Command(toolPath, "--defaults-extra-file="+optionFile)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	AppLogger.Log("Executing %s -h %s -P %s -u %s", toolPath, creds.Host, creds.Port, creds.Username)
+	return cmd.Run()
+}
+
+// backupConfigDataDir copies a stopped configuration's data directory to dst.
+// A data directory that isn't entirely the current user's, such as one owned
+// by mysql with mode 0700, is copied by the elevated helper.
+func backupConfigDataDir(cfg MariaDBConfig, dataDir, dst string) error {
+	if runtime.GOOS == "windows" || IsRoot() || ownsTree(dataDir) {
+		return backupDataDir(dataDir, dst)
+	}
+	absConfigFile, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("cannot get absolute path for config: %v", err)
+	}
+	return runElevatedHelper(nil, HelperBackupDataDir, absConfigFile, dst)
+}
+
+// ownsTree reports whether the current user can read a directory tree and owns
+// everything in it
+func ownsTree(dir string) bool {
+	uid := os.Getuid()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if owner, ok := fileOwnerUID(path); !ok || owner != uid {
+			return fs.ErrPermission
+		}
+		return nil
+	})
+	return err == nil
+}
+
+// backupDataDir copies a stopped server's data directory, keeping permissions
+// and owners, after checking that the destination filesystem has room for it.
+// A partial copy is removed.
+func backupDataDir(src, dst string) error {
+	var size int64
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if free, err := GetFreeDiskSpace(filepath.Dir(dst)); err == nil && free < size {
+		return fmt.Errorf("not enough free space for a %s backup next to %s (%s free)", FormatBytes(size), src, FormatBytes(free))
+	}
+
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			err = os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			err = copyFile(path, target, info.Mode().Perm())
+		default:
+			// Sockets and pid files of a stopped server are not worth keeping
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return copyOwner(path, target)
+	})
+	if err != nil {
+		os.RemoveAll(dst)
+	}
+	return err
+}
+
+// copyOwner gives dst the owner and group of src where they differ
+func copyOwner(src, dst string) error {
+	uid, uidOK := fileOwnerUID(src)
+	gid, gidOK := fileOwnerGID(src)
+	if !uidOK || !gidOK {
+		return nil
+	}
+	dstUID, _ := fileOwnerUID(dst)
+	dstGID, _ := fileOwnerGID(dst)
+	if uid == dstUID && gid == dstGID {
+		return nil
+	}
+	return os.Lchown(dst, uid, gid)
+}
+
+// copyFile copies a regular file with the given permissions
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -1,9 +1,12 @@
 package core
 
 import (
+	"fmt"
+	"io"
 	"net"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -21,6 +24,91 @@ func GetExecutableName(base string) string {
 	return base
 }
 
+// FormatBytes formats a byte count as a human-readable size
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// FormatUptime formats a number of seconds as a compact duration (e.g. "2d 3h 4m")
+func FormatUptime(seconds int64) string {
+	days := seconds / 86400
+	hours := (seconds % 86400) / 3600
+	minutes := (seconds % 3600) / 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm %ds", minutes, seconds%60)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// Sparkline renders a series of values as a line of Unicode block characters
+func Sparkline(values []float64) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	if len(values) == 0 {
+		return ""
+	}
+
+	low, high := values[0], values[0]
+	for _, v := range values {
+		if v < low {
+			low = v
+		}
+		if v > high {
+			high = v
+		}
+	}
+
+	line := make([]rune, len(values))
+	for i, v := range values {
+		index := 0
+		if high > low {
+			index = int((v - low) / (high - low) * float64(len(blocks)-1))
+		}
+		line[i] = blocks[index]
+	}
+	return string(line)
+}
+
+// TailFile returns up to the last n lines of a file, or "" if it cannot be read.
+// Only the end of the file is read, so large logs are cheap to tail.
+func TailFile(path string, n int) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	const maxTailBytes = 64 * 1024
+	if info, err := f.Stat(); err == nil && info.Size() > maxTailBytes {
+		f.Seek(info.Size()-maxTailBytes, io.SeekStart)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
 // IsDirEmpty checks if a directory is empty
 func IsDirEmpty(dir string) (bool, error) {
 	f, err := os.Open(dir)
@@ -30,12 +118,23 @@ func IsDirEmpty(dir string) (bool, error) {
 	defer f.Close()
 
 	_, err = f.Readdirnames(1)
-	if err == os.ErrNotExist || err == nil {
+	if err == io.EOF || err == nil {
 		return err != nil, nil
 	}
 	return false, err
 }
 
+// IsDirWritable checks if the current user can create files in a directory
+func IsDirWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".dbswitcher-write-test-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
 // IsPortAvailable checks if a port is available for binding
 func IsPortAvailable(port string) bool {
 	ln, err := net.Listen("tcp", ":"+port)
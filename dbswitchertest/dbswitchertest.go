@@ -0,0 +1,198 @@
+// Package dbswitchertest starts throwaway MariaDB/MySQL instances from Go tests.
+//
+//	func TestOrders(t *testing.T) {
+//		db := dbswitchertest.Start(t, dbswitchertest.Options{
+//			Database:  "shop",
+//			SeedFiles: []string{"testdata/schema.sql", "testdata/orders.sql"},
+//		})
+//		conn, err := sql.Open("mysql", db.DSN())
+//		...
+//	}
+//
+// Each instance is an ephemeral DBSwitcher instance: a free port, a data
+// directory under tmpfs when available, initialized by the server's own tools
+// and removed by t.Cleanup. Tests are skipped when no server is installed.
+package dbswitchertest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"mariadb-monitor/core"
+)
+
+// Options configures an instance. The zero value starts an empty server with
+// the installation DBSwitcher detects, reachable as root without a password.
+type Options struct {
+	// BinDir is the server's bin directory, or the name of a registered
+	// installation. It defaults to the installation found by DetectMariaDBBin.
+	BinDir string
+
+	// Database is created after initialization and selected by DSN
+	Database string
+
+	// User is created with a generated password and all privileges on
+	// Database. The password is only kept in memory; no keyring is needed.
+	User string
+
+	// SeedFiles are run in order after Database and User are created, followed
+	// by the .sql files of SeedDir in name order. Statements run in Database.
+	SeedFiles []string
+	SeedDir   string
+
+	// Timezones loads the time zone tables
+	Timezones bool
+
+	// ServerOptions are added to the [mysqld] group, e.g. "sql_mode": "ANSI"
+	ServerOptions map[string]string
+}
+
+// Instance is a running throwaway server
+type Instance struct {
+	Host     string
+	Port     string
+	Socket   string // Empty on Windows
+	User     string
+	Password string
+	Database string
+	Dir      string // Holds the option file, data directory, socket and logs
+}
+
+// DSN returns a Go MySQL driver data source name for the instance
+func (i *Instance) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", i.User, i.Password, i.Host, i.Port, i.Database)
+}
+
+var (
+	initOnce sync.Once
+	// startMu serializes starts, so parallel tests don't pick the same free port
+	startMu sync.Mutex
+)
+
+// Start starts an instance for the test and stops and removes it when the
+// test and its subtests finish. It fails the test if the server can't start
+// and skips it if no server is installed.
+func Start(t testing.TB, opts Options) *Instance {
+	t.Helper()
+	initOnce.Do(core.Init)
+
+	binDir := opts.BinDir
+	if binDir == "" {
+		binDir = core.DetectMariaDBBin()
+	} else if inst := core.FindInstallation(binDir); inst != nil {
+		binDir = inst.BinDir
+	}
+	if core.GetBinaryVersion(binDir) == "Unknown" {
+		t.Skipf("dbswitchertest: no MariaDB or MySQL server found in %q", binDir)
+	}
+
+	templateDir := t.TempDir()
+	templatePath, err := writeTemplate(templateDir, binDir, opts)
+	if err != nil {
+		t.Fatalf("dbswitchertest: %v", err)
+	}
+	template := core.ConfigForPath(templatePath)
+
+	startMu.Lock()
+	ephemeral, err := core.StartEphemeral(context.Background(), &template)
+	startMu.Unlock()
+	if err != nil {
+		t.Fatalf("dbswitchertest: failed to start %s: %v", core.GetBinaryVersion(binDir), err)
+	}
+	t.Cleanup(func() {
+		if err := ephemeral.Destroy(); err != nil {
+			t.Errorf("dbswitchertest: %v", err)
+		}
+	})
+
+	creds := ephemeral.Credentials()
+	return &Instance{
+		Host:     "127.0.0.1",
+		Port:     ephemeral.Port,
+		Socket:   ephemeral.Socket,
+		User:     creds.Username,
+		Password: creds.Password,
+		Database: opts.Database,
+		Dir:      ephemeral.Dir,
+	}
+}
+
+// writeTemplate writes the configuration the instance is started from, with
+// the seed files copied into a seed directory in the order they run
+func writeTemplate(dir, binDir string, opts Options) (string, error) {
+	seedFiles := append([]string{}, opts.SeedFiles...)
+	if opts.SeedDir != "" {
+		matches, err := filepath.Glob(filepath.Join(opts.SeedDir, "*.sql"))
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(matches)
+		seedFiles = append(seedFiles, matches...)
+	}
+
+	var b strings.Builder
+	b.WriteString("[mysqld]\n")
+	keys := make([]string, 0, len(opts.ServerOptions))
+	for key := range opts.ServerOptions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s = %s\n", key, opts.ServerOptions[key])
+	}
+
+	fmt.Fprintf(&b, "\n[dbswitcher]\nbin = %s\n", binDir)
+	if opts.Database != "" {
+		fmt.Fprintf(&b, "init-database = %s\n", opts.Database)
+	}
+	if opts.User != "" {
+		fmt.Fprintf(&b, "init-user = %s\n", opts.User)
+	}
+	if opts.Timezones {
+		b.WriteString("init-timezones = yes\n")
+	}
+	if len(seedFiles) > 0 {
+		seedDir := filepath.Join(dir, "seed")
+		if err := os.Mkdir(seedDir, 0755); err != nil {
+			return "", err
+		}
+		for i, file := range seedFiles {
+			if err := copySeedFile(file, filepath.Join(seedDir, fmt.Sprintf("%04d-%s", i, filepath.Base(file)))); err != nil {
+				return "", err
+			}
+		}
+		fmt.Fprintf(&b, "init-seed-dir = %s\n", seedDir)
+	}
+
+	path := filepath.Join(dir, "dbswitchertest.cnf")
+	return path, os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// copySeedFile copies a seed file, adding the .sql extension the init step looks for
+func copySeedFile(src, dst string) error {
+	if !strings.EqualFold(filepath.Ext(dst), ".sql") {
+		dst += ".sql"
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open seed file: %v", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,132 @@
+package dbswitchertest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	src := t.TempDir()
+	schema := filepath.Join(src, "schema.sql")
+	data := filepath.Join(src, "orders.txt")
+	os.WriteFile(schema, []byte("CREATE TABLE orders (id INT);\n"), 0644)
+	os.WriteFile(data, []byte("INSERT INTO orders VALUES (1);\n"), 0644)
+	seedDir := filepath.Join(src, "seed")
+	os.Mkdir(seedDir, 0755)
+	os.WriteFile(filepath.Join(seedDir, "b.sql"), []byte("SELECT 2;\n"), 0644)
+	os.WriteFile(filepath.Join(seedDir, "a.sql"), []byte("SELECT 1;\n"), 0644)
+	os.WriteFile(filepath.Join(seedDir, "notes.txt"), []byte("not a seed\n"), 0644)
+
+	dir := t.TempDir()
+	path, err := writeTemplate(dir, "/opt/mariadb/bin", Options{
+		Database:      "shop",
+		User:          "app",
+		Timezones:     true,
+		SeedFiles:     []string{schema, data},
+		SeedDir:       seedDir,
+		ServerOptions: map[string]string{"sql_mode": "ANSI", "character_set_server": "utf8mb4"},
+	})
+	if err != nil {
+		t.Fatalf("writeTemplate: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[mysqld]\ncharacter_set_server = utf8mb4\nsql_mode = ANSI\n\n" +
+		"[dbswitcher]\nbin = /opt/mariadb/bin\ninit-database = shop\ninit-user = app\ninit-timezones = yes\n" +
+		"init-seed-dir = " + filepath.Join(dir, "seed") + "\n"
+	if string(content) != want {
+		t.Errorf("template:\n%s\nwant:\n%s", content, want)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "seed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// Seed files first, in order, then the seed directory's .sql files by name
+	if got := strings.Join(names, " "); got != "0000-schema.sql 0001-orders.txt.sql 0002-a.sql 0003-b.sql" {
+		t.Errorf("seed files = %s", got)
+	}
+}
+
+func TestWriteTemplateWithoutOptions(t *testing.T) {
+	dir := t.TempDir()
+	path, err := writeTemplate(dir, "/usr/bin", Options{})
+	if err != nil {
+		t.Fatalf("writeTemplate: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != "[mysqld]\n\n[dbswitcher]\nbin = /usr/bin\n" {
+		t.Errorf("template:\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "seed")); !os.IsNotExist(err) {
+		t.Error("seed directory created without seed files")
+	}
+}
+
+func TestInstanceDSN(t *testing.T) {
+	instance := &Instance{Host: "127.0.0.1", Port: "3307", User: "app", Password: "s3cret", Database: "shop"}
+	if dsn := instance.DSN(); dsn != "app:s3cret@tcp(127.0.0.1:3307)/shop" {
+		t.Errorf("DSN = %s", dsn)
+	}
+}
+
+// isolateHome keeps Start from reading or writing the user's DBSwitcher settings
+func isolateHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+}
+
+func TestStartSkipsWithoutServer(t *testing.T) {
+	isolateHome(t)
+	empty := t.TempDir()
+
+	var skipped bool
+	t.Run("start", func(t *testing.T) {
+		defer func() { skipped = t.Skipped() }()
+		Start(t, Options{BinDir: empty})
+		t.Error("Start returned without a server")
+	})
+	if !skipped {
+		t.Error("test was not skipped")
+	}
+}
+
+func TestStartCreatesUserAndSeeds(t *testing.T) {
+	isolateHome(t)
+	seed := filepath.Join(t.TempDir(), "schema.sql")
+	os.WriteFile(seed, []byte("CREATE TABLE orders (id INT);\nINSERT INTO orders VALUES (1), (2);\n"), 0644)
+
+	db := Start(t, Options{Database: "shop", User: "app", SeedFiles: []string{seed}})
+	if db.User != "app" || db.Password == "" {
+		t.Fatalf("credentials = %s/%q, want app with a generated password", db.User, db.Password)
+	}
+
+	client, err := exec.LookPath("mariadb")
+	if err != nil {
+		if client, err = exec.LookPath("mysql"); err != nil {
+			t.Skip("no command-line client to query the instance")
+		}
+	}
+	cmd := exec.Command(client, "--protocol=TCP", "-h", db.Host, "-P", db.Port, "-u", db.User, db.Database,
+		"-N", "-e", "SELECT COUNT(*) FROM orders")
+	cmd.Env = append(os.Environ(), "MYSQL_PWD="+db.Password)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("query failed: %v: %s", err, output)
+	}
+	if got := strings.TrimSpace(string(output)); got != "2" {
+		t.Errorf("orders = %s, want 2", got)
+	}
+}
@@ -17,6 +17,9 @@ var (
 	GlobalConfigList    *widget.List    // Global reference to list in Configurations tab
 )
 
+// restoreOnLaunch is set when the app was launched at login
+var restoreOnLaunch bool
+
 // Run starts the GUI application with default settings
 func Run() error {
 	return RunWithOptions(false)
@@ -44,7 +47,13 @@ func RunWithOptions(startMinimized bool) error {
 			quickActionsCard,
 		)),
 		container.NewTabItem("Configurations", configCard),
+		container.NewTabItem("SQL Console", CreateSQLConsole()),
 	)
+	tabs.OnSelected = func(tab *container.TabItem) {
+		if tab.Text == "SQL Console" {
+			RefreshSQLConsole()
+		}
+	}
 
 	// Create menu
 	MainWindow.SetMainMenu(CreateMainMenu())
@@ -61,12 +70,25 @@ func RunWithOptions(startMinimized bool) error {
 	// Set up close handler to exit application
 	MainWindow.SetCloseIntercept(func() {
 		core.AppLogger.Log("Main window closing - shutting down application")
+		core.StopMetricsEndpoint()
+		core.StopProxy()
+		core.StopOnDemand()
 		core.AppLogger.Close()
 		FyneApp.Quit()
 	})
 
 	// Start auto-refresh
 	StartAutoRefresh()
+	StartMetricsEndpoint()
+	StartProxy()
+	core.StartOnDemand()
+	WatchInitProgress()
+	core.StartSupervisor()
+	core.UseGraphicalElevation()
+	
+	if restoreOnLaunch {
+		go restoreConfigurationOnLogin()
+	}
 	
 	if startMinimized {
 		core.AppLogger.Info("Starting application minimized to system tray")
@@ -81,6 +103,21 @@ func RunWithOptions(startMinimized bool) error {
 	return nil
 }
 
+// RunAtLogin starts the GUI minimized, as launched by the auto-start entry,
+// and restores the default or last used configuration if enabled
+func RunAtLogin() error {
+	restoreOnLaunch = true
+	return RunWithOptions(true)
+}
+
+// restoreConfigurationOnLogin starts the configured database and refreshes the UI
+func restoreConfigurationOnLogin() {
+	if err := core.RestoreConfigOnLogin(); err != nil {
+		core.AppLogger.Error("Failed to restore configuration on login: %v", err)
+	}
+	RefreshMainUI()
+}
+
 // RunTray starts the application in system tray mode
 func RunTray() error {
 	// Initialize the Fyne app and create window (but don't show it)
@@ -104,7 +141,13 @@ func RunTray() error {
 			quickActionsCard,
 		)),
 		container.NewTabItem("Configurations", configCard),
+		container.NewTabItem("SQL Console", CreateSQLConsole()),
 	)
+	tabs.OnSelected = func(tab *container.TabItem) {
+		if tab.Text == "SQL Console" {
+			RefreshSQLConsole()
+		}
+	}
 	
 	// Create menu
 	MainWindow.SetMainMenu(CreateMainMenu())
@@ -120,10 +163,30 @@ func RunTray() error {
 	
 	// Start auto-refresh
 	StartAutoRefresh()
+	StartMetricsEndpoint()
+	StartProxy()
+	core.StartOnDemand()
+	WatchInitProgress()
+	core.StartSupervisor()
+	core.UseGraphicalElevation()
 	
 	// Create system tray (this starts its own event loop)
 	CreateSystemTray()
 	
 	// Note: systray.Run() blocks, so this won't return until systray.Quit() is called
 	return nil
-}
\ No newline at end of file
+}
+
+// StartMetricsEndpoint starts the Prometheus endpoint if enabled, logging any failure
+func StartMetricsEndpoint() {
+	if err := core.StartMetricsEndpoint(); err != nil {
+		core.AppLogger.Error("Failed to start metrics endpoint: %v", err)
+	}
+}
+
+// StartProxy starts the TCP proxy if enabled, logging any failure
+func StartProxy() {
+	if err := core.StartProxy(); err != nil {
+		core.AppLogger.Error("Failed to start proxy: %v", err)
+	}
+}
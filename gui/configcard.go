@@ -1,7 +1,10 @@
 package gui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -47,7 +50,7 @@ func CreateConfigCard() fyne.CanvasObject {
 			descLabel := c.Objects[1].(*widget.Label)
 			
 			nameLabel.SetText(cfg.Name)
-			portLabel.SetText("Port: " + cfg.Port)
+			portLabel.SetText(fmt.Sprintf("Port: %s · %s", cfg.Port, core.ConfigServerVersion(cfg)))
 			
 			status := "Ready"
 			if cfg.IsActive && core.CurrentStatus.IsRunning {
@@ -69,8 +72,14 @@ func CreateConfigCard() fyne.CanvasObject {
 	// Handle selection
 	GlobalConfigList.OnSelected = func(id widget.ListItemID) {
 		selectedConfig = id
+		if id >= 0 && id < len(core.AvailableConfigs) {
+			ShowConfigDetails(core.AvailableConfigs[id])
+		}
 	}
 
+	// Details panel for the selected configuration
+	detailsCard := createConfigDetailsCard()
+
 	// Status bar
 	statusBar := widget.NewLabel("")
 	updateStatusBar := func() {
@@ -92,7 +101,7 @@ func CreateConfigCard() fyne.CanvasObject {
 			statusBar.SetText(fmt.Sprintf("Starting %s configuration...", cfg.Name))
 			
 			go func(config core.MariaDBConfig) {
-				err := core.StartMariaDBWithConfig(config.Path)
+				err := core.SwitchToConfig(config, nil)
 				
 				// Update status after operation
 				RefreshMainUI()
@@ -108,7 +117,12 @@ func CreateConfigCard() fyne.CanvasObject {
 							Content: fmt.Sprintf("Failed to start %s: %v", config.Name, err),
 						})
 						statusBar.SetText("Failed to start MariaDB")
-						dialog.ShowError(err, MainWindow)
+						var ownershipErr *core.OwnershipError
+						if errors.As(err, &ownershipErr) {
+							confirmFixOwnership(config, ownershipErr)
+						} else {
+							dialog.ShowError(err, MainWindow)
+						}
 					} else {
 						fyne.CurrentApp().SendNotification(&fyne.Notification{
 							Title:   "MariaDB Started",
@@ -168,6 +182,35 @@ func CreateConfigCard() fyne.CanvasObject {
 		}
 	})
 
+	fixPermsBtn := widget.NewButtonWithIcon("Fix Permissions", theme.WarningIcon(), func() {
+		if selectedConfig >= 0 && selectedConfig < len(core.AvailableConfigs) {
+			confirmFixOwnership(core.AvailableConfigs[selectedConfig], nil)
+		}
+	})
+
+	resetBtn := widget.NewButtonWithIcon("Reset", theme.HistoryIcon(), func() {
+		if selectedConfig >= 0 && selectedConfig < len(core.AvailableConfigs) {
+			cfg := core.AvailableConfigs[selectedConfig]
+			confirmResetConfig(cfg, func() {
+				GlobalConfigList.Refresh()
+				updateStatusBar()
+			})
+		}
+	})
+
+	sqlShellBtn := widget.NewButtonWithIcon("SQL Shell", theme.ComputerIcon(), func() {
+		if selectedConfig >= 0 && selectedConfig < len(core.AvailableConfigs) {
+			openSQLShell(core.AvailableConfigs[selectedConfig])
+		}
+	})
+
+	var copyConnectionBtn *widget.Button
+	copyConnectionBtn = widget.NewButtonWithIcon("Copy Connection", theme.ContentCopyIcon(), func() {
+		if selectedConfig >= 0 && selectedConfig < len(core.AvailableConfigs) {
+			showCopyConnectionMenu(core.AvailableConfigs[selectedConfig], copyConnectionBtn)
+		}
+	})
+
 	openFolderBtn := widget.NewButtonWithIcon("Open Folder", theme.FolderOpenIcon(), func() {
 		OpenFolder(core.AppConfig.ConfigPath)
 	})
@@ -175,6 +218,7 @@ func CreateConfigCard() fyne.CanvasObject {
 	refreshBtn := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), func() {
 		RefreshConfigurations()
 		updateStatusBar()
+		RefreshConfigDetails(true)
 	})
 
 	// Toolbar
@@ -184,7 +228,11 @@ func CreateConfigCard() fyne.CanvasObject {
 		widget.NewSeparator(),
 		editBtn,
 		deleteBtn,
+		fixPermsBtn,
+		resetBtn,
 		widget.NewSeparator(),
+		sqlShellBtn,
+		copyConnectionBtn,
 		openFolderBtn,
 		refreshBtn,
 	)
@@ -192,6 +240,10 @@ func CreateConfigCard() fyne.CanvasObject {
 	// Info label
 	infoLabel := widget.NewLabel("Select a configuration from the list to start, edit, or delete it.")
 
+	// List on the left, details of the selected configuration on the right
+	split := container.NewHSplit(GlobalConfigList, detailsCard)
+	split.Offset = 0.6
+
 	// Main content layout
 	content := container.NewBorder(
 		container.NewVBox(
@@ -200,11 +252,299 @@ func CreateConfigCard() fyne.CanvasObject {
 			statusBar,
 			infoLabel,
 		),
-		nil,              // bottom
-		nil,              // left
-		nil,              // right
-		GlobalConfigList, // center - this will fill the remaining space
+		nil,   // bottom
+		nil,   // left
+		nil,   // right
+		split, // center - this will fill the remaining space
 	)
 
 	return content
+}
+
+// openSQLShell opens the SQL client of a running configuration in a terminal.
+// The terminal runs 'dbswitcher shell', which keeps the credentials off the
+// command line.
+func openSQLShell(cfg core.MariaDBConfig) {
+	if !core.IsConfigActive(cfg, core.CurrentStatus) && !core.IsOnDemandConfig(cfg) {
+		dialog.ShowInformation("SQL Shell", fmt.Sprintf("%s is not running. Start it first.", cfg.Name), MainWindow)
+		return
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		dialog.ShowError(err, MainWindow)
+		return
+	}
+	if err := OpenInTerminal(executable, "shell", cfg.Name); err != nil {
+		dialog.ShowError(err, MainWindow)
+	}
+}
+
+// showCopyConnectionMenu shows the connection formats below anchor and copies
+// the chosen one for a configuration to the clipboard
+func showCopyConnectionMenu(cfg core.MariaDBConfig, anchor fyne.CanvasObject) {
+	details := core.GetConnectionDetails(cfg)
+
+	formatItems := func(includePassword bool) []*fyne.MenuItem {
+		var items []*fyne.MenuItem
+		for _, f := range core.ConnectionFormats {
+			format := f.Format
+			items = append(items, fyne.NewMenuItem(f.Label, func() {
+				text, err := details.Format(format, includePassword)
+				if err != nil {
+					dialog.ShowError(err, MainWindow)
+					return
+				}
+				fyne.CurrentApp().Clipboard().SetContent(strings.TrimRight(text, "\n"))
+			}))
+		}
+		return items
+	}
+
+	withoutPassword := fyne.NewMenuItem("Without Password", nil)
+	withoutPassword.ChildMenu = fyne.NewMenu("", formatItems(false)...)
+	items := append(formatItems(true), fyne.NewMenuItemSeparator(), withoutPassword)
+
+	canvas := fyne.CurrentApp().Driver().CanvasForObject(anchor)
+	position := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor).AddXY(0, anchor.Size().Height)
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), canvas, position)
+}
+
+// confirmFixOwnership asks before giving a configuration's data directory to the
+// OS user its server runs as. The change runs as root through pkexec.
+func confirmFixOwnership(cfg core.MariaDBConfig, cause *core.OwnershipError) {
+	message := fmt.Sprintf("Give %s and everything in it to user '%s'?\n\nThis requires administrator privileges.",
+		core.ResolveDataDir(cfg), core.ServerRunUser(cfg))
+	if cause != nil {
+		message = fmt.Sprintf("%s is owned by '%s', but the server runs as '%s'.\n\n%s",
+			cause.Path, cause.Owner, cause.RunUser, message)
+	}
+
+	dialog.ShowConfirm("Fix Data Directory Ownership", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		go func() {
+			err := core.FixDataDirOwnership(cfg)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, MainWindow)
+				} else {
+					dialog.ShowInformation("Ownership Fixed",
+						fmt.Sprintf("The data directory of %s now belongs to '%s'.", cfg.Name, core.ServerRunUser(cfg)), MainWindow)
+				}
+			})
+		}()
+	}, MainWindow)
+}
+
+// confirmResetConfig asks how to dispose of a configuration's data directory
+// before resetting it. Deleting it permanently requires typing the
+// configuration's name. onDone runs on the UI thread after the reset.
+func confirmResetConfig(cfg core.MariaDBConfig, onDone func()) {
+	if core.IsProtectedConfig(cfg) {
+		dialog.ShowInformation("Protected Configuration",
+			fmt.Sprintf("%s is protected and can't be reset.\n\nRemove 'protected' from its [dbswitcher] group to allow it.", cfg.Name), MainWindow)
+		return
+	}
+
+	dataDir := core.ResolveDataDir(cfg)
+	disposal := widget.NewRadioGroup([]string{"Move to trash", "Delete permanently"}, nil)
+	disposal.SetSelected("Move to trash")
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(cfg.Name)
+	nameEntry.Disable()
+	disposal.OnChanged = func(choice string) {
+		if choice == "Delete permanently" {
+			nameEntry.Enable()
+		} else {
+			nameEntry.Disable()
+		}
+	}
+
+	message := widget.NewLabel(fmt.Sprintf("Stop %s, replace %s with a freshly initialized data directory, run its seed files and start it again?\n\nThe trash is %s.",
+		cfg.Name, dataDir, core.GetTrashDir(dataDir)))
+	message.Wrapping = fyne.TextWrapWord
+	items := []*widget.FormItem{
+		widget.NewFormItem("", message),
+		widget.NewFormItem("Old data", disposal),
+		widget.NewFormItem("Type name to delete", nameEntry),
+	}
+
+	form := dialog.NewForm("Reset Configuration", "Reset", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		deleteData := disposal.Selected == "Delete permanently"
+		if deleteData && nameEntry.Text != cfg.Name {
+			dialog.ShowInformation("Reset Cancelled", "The typed name did not match, nothing was deleted.", MainWindow)
+			return
+		}
+		go func() {
+			result, err := core.ResetConfig(cfg, deleteData, nil)
+			RefreshMainUI()
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, MainWindow)
+				} else if result.TrashDir != "" {
+					dialog.ShowInformation("Reset Complete",
+						fmt.Sprintf("%s was reset and started.\n\nOld data directory: %s", cfg.Name, result.TrashDir), MainWindow)
+				} else {
+					dialog.ShowInformation("Reset Complete", fmt.Sprintf("%s was reset and started.", cfg.Name), MainWindow)
+				}
+				onDone()
+			})
+		}()
+	}, MainWindow)
+	form.Resize(fyne.NewSize(520, 320))
+	form.Show()
+}
+
+// Details panel state for the selected configuration
+var (
+	configDetailsCard     *widget.Card
+	configDetailsLabel    *widget.Label
+	configDetailsSelected string // Path of the configuration shown in the panel
+	installationSelect    *widget.Select
+)
+
+// defaultInstallationOption is the installation choice that removes the pin
+const defaultInstallationOption = "Default"
+
+// createConfigDetailsCard creates the detail area showing data directory usage
+func createConfigDetailsCard() *widget.Card {
+	configDetailsLabel = widget.NewLabel("Select a configuration to see its data directory usage.")
+	configDetailsLabel.Wrapping = fyne.TextWrapWord
+	configDetailsSelected = ""
+
+	installationSelect = widget.NewSelect(nil, nil)
+	installationSelect.Disable()
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel("Installation:"), nil, installationSelect),
+		nil, nil, nil,
+		container.NewVScroll(configDetailsLabel),
+	)
+	configDetailsCard = widget.NewCard("Details", "", content)
+	return configDetailsCard
+}
+
+// updateInstallationSelect shows the installation a configuration is pinned to and
+// pins it to another one when the selection changes
+func updateInstallationSelect(cfg core.MariaDBConfig) {
+	installationSelect.OnChanged = nil
+
+	options := []string{defaultInstallationOption}
+	for _, inst := range core.AppConfig.Installations {
+		options = append(options, inst.Name)
+	}
+	installationSelect.Options = options
+
+	selected := core.AppConfig.ConfigInstallations[cfg.Name]
+	if selected == "" {
+		selected = defaultInstallationOption
+	}
+	installationSelect.SetSelected(selected)
+
+	// Containers bring their server, and bin= in the file takes precedence over the pin
+	if core.IsContainerConfig(cfg) || cfg.Options["bin"] != "" {
+		installationSelect.Disable()
+		return
+	}
+	installationSelect.Enable()
+
+	installationSelect.OnChanged = func(name string) {
+		if name == defaultInstallationOption {
+			name = ""
+		}
+		if err := core.PinInstallation(cfg.Name, name); err != nil {
+			dialog.ShowError(err, MainWindow)
+			return
+		}
+		if err := core.SaveConfig(); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save settings: %v", err), MainWindow)
+		}
+		if GlobalConfigList != nil {
+			GlobalConfigList.Refresh()
+		}
+	}
+}
+
+// ShowConfigDetails shows data directory usage for a configuration in the details panel
+func ShowConfigDetails(cfg core.MariaDBConfig) {
+	if configDetailsCard == nil {
+		return
+	}
+
+	configDetailsSelected = cfg.Path
+	configDetailsCard.SetTitle(cfg.Name)
+	configDetailsCard.SetSubTitle(cfg.Description)
+	configDetailsLabel.SetText("Calculating disk usage...")
+	updateInstallationSelect(cfg)
+
+	RefreshConfigDetails(false)
+}
+
+// RefreshConfigDetails updates the details panel for the selected configuration.
+// Cached usage is reused unless force is set, so periodic refreshes stay cheap.
+// It must run on the UI thread, which owns the selection.
+func RefreshConfigDetails(force bool) {
+	if configDetailsCard == nil || configDetailsSelected == "" {
+		return
+	}
+
+	cfg := core.FindConfigByPath(configDetailsSelected)
+	if cfg == nil {
+		return
+	}
+
+	go func(config core.MariaDBConfig) {
+		running := core.IsConfigActive(config, core.CurrentStatus)
+
+		var usage *core.DataDirUsage
+		var err error
+		if force {
+			usage, err = core.RefreshDataDirUsage(config, running)
+		} else {
+			usage, err = core.GetDataDirUsage(config, running)
+		}
+
+		fyne.Do(func() {
+			// Selection may have changed while computing
+			if configDetailsSelected != config.Path {
+				return
+			}
+			if err != nil {
+				configDetailsLabel.SetText(fmt.Sprintf("Data Directory: %s\n\n%v", core.ResolveDataDir(config), err))
+				return
+			}
+			configDetailsLabel.SetText(formatDataDirUsage(usage))
+		})
+	}(*cfg)
+}
+
+// formatDataDirUsage renders a usage report for the details panel
+func formatDataDirUsage(usage *core.DataDirUsage) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Data Directory: %s\n", usage.DataDir)
+	fmt.Fprintf(&b, "Total Size: %s\n", core.FormatBytes(usage.TotalBytes))
+	if usage.FreeBytes >= 0 {
+		fmt.Fprintf(&b, "Free Space: %s\n", core.FormatBytes(usage.FreeBytes))
+	} else {
+		fmt.Fprintf(&b, "Free Space: unknown\n")
+	}
+	fmt.Fprintf(&b, "InnoDB Logs: %s\n", core.FormatBytes(usage.InnoDBLogBytes))
+	fmt.Fprintf(&b, "InnoDB Undo: %s\n", core.FormatBytes(usage.UndoBytes))
+	fmt.Fprintf(&b, "Last Modified: %s\n", usage.LastModified.Format("2006-01-02 15:04:05"))
+
+	fmt.Fprintf(&b, "\nSchemas (%s):\n", usage.SchemaSource)
+	if len(usage.Schemas) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for _, schema := range usage.Schemas {
+		fmt.Fprintf(&b, "  %s: %s\n", schema.Name, core.FormatBytes(schema.SizeBytes))
+	}
+
+	fmt.Fprintf(&b, "\nUpdated %s", usage.ComputedAt.Format("15:04:05"))
+	return b.String()
 }
\ No newline at end of file
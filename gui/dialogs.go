@@ -14,6 +14,8 @@ import (
 	"fyne.io/fyne/v2/cmd/fyne_settings/settings"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"mariadb-monitor/core"
 )
@@ -155,6 +157,9 @@ func ShowSettings() {
 		connectionTimeoutEntry = connEntry
 		tabs.Append(container.NewTabItem("Advanced", advancedTab))
 		
+		// Notification Channels Tab
+		tabs.Append(container.NewTabItem("Channels", createNotificationChannelsTab(settingsWindow)))
+		
 		// About Tab
 		aboutTab := createAboutSettingsTab()
 		tabs.Append(container.NewTabItem("About", aboutTab))
@@ -187,10 +192,29 @@ func ShowSettings() {
 					RestartAutoRefresh()
 				}
 				
+				// Apply the remaining settings even if one fails, and report all failures
+				var problems []string
+				
+				// Apply metrics endpoint changes
+				if err := core.RestartMetricsEndpoint(); err != nil {
+					core.AppLogger.Error("Failed to restart metrics endpoint: %v", err)
+					problems = append(problems, fmt.Sprintf("the metrics endpoint could not start: %v", err))
+				}
+				
+				// Apply proxy changes
+				if err := core.RestartProxy(); err != nil {
+					core.AppLogger.Error("Failed to restart proxy: %v", err)
+					problems = append(problems, fmt.Sprintf("the proxy could not start: %v", err))
+				}
+				
 				// Update auto-start setting
 				if err := core.UpdateAutoStartSetting(); err != nil {
 					core.AppLogger.Error("Failed to update auto-start setting: %v", err)
-					dialog.ShowError(fmt.Errorf("Settings saved but failed to update auto-start: %v", err), settingsWindow)
+					problems = append(problems, fmt.Sprintf("failed to update auto-start: %v", err))
+				}
+				
+				if len(problems) > 0 {
+					dialog.ShowError(fmt.Errorf("Settings saved but %s", strings.Join(problems, "; ")), settingsWindow)
 				} else {
 					dialog.ShowInformation("Settings Saved", "Settings have been saved successfully.", settingsWindow)
 				}
@@ -227,6 +251,9 @@ func ShowSettings() {
 	})
 }
 
+// lastUsedConfigOption is the restore choice that follows the last started configuration
+const lastUsedConfigOption = "(Last used)"
+
 // createGeneralSettingsTabWithEntry creates the general settings tab and returns the refresh interval entry
 func createGeneralSettingsTabWithEntry() (fyne.CanvasObject, *widget.Entry) {
 	// Auto-refresh settings
@@ -260,6 +287,36 @@ func createGeneralSettingsTabWithEntry() (fyne.CanvasObject, *widget.Entry) {
 	})
 	autoStartCheck.SetChecked(core.AppConfig.AutoStartWithSystem)
 	
+	// Login restore settings
+	defaultConfigOptions := []string{lastUsedConfigOption}
+	for _, cfg := range core.AvailableConfigs {
+		defaultConfigOptions = append(defaultConfigOptions, cfg.Name)
+	}
+	defaultConfigSelect := widget.NewSelect(defaultConfigOptions, func(selected string) {
+		if selected == lastUsedConfigOption {
+			core.AppConfig.DefaultConfig = ""
+		} else {
+			core.AppConfig.DefaultConfig = selected
+		}
+	})
+	if core.AppConfig.DefaultConfig != "" {
+		defaultConfigSelect.SetSelected(core.AppConfig.DefaultConfig)
+	} else {
+		defaultConfigSelect.SetSelected(lastUsedConfigOption)
+	}
+	
+	restoreOnLoginCheck := widget.NewCheck("Start a configuration when launched at login", func(checked bool) {
+		core.AppConfig.RestoreOnLogin = checked
+		defaultConfigSelect.Enable()
+		if !checked {
+			defaultConfigSelect.Disable()
+		}
+	})
+	restoreOnLoginCheck.SetChecked(core.AppConfig.RestoreOnLogin)
+	if !core.AppConfig.RestoreOnLogin {
+		defaultConfigSelect.Disable()
+	}
+	
 	// Log level settings
 	logLevelSelect := widget.NewSelect([]string{"DEBUG", "INFO", "WARN", "ERROR"}, func(selected string) {
 		core.AppConfig.LogLevel = selected
@@ -275,6 +332,8 @@ func createGeneralSettingsTabWithEntry() (fyne.CanvasObject, *widget.Entry) {
 			widget.NewFormItem("", widget.NewSeparator()),
 			widget.NewFormItem("Start Minimized", startMinimizedCheck),
 			widget.NewFormItem("Auto-start with System", autoStartCheck),
+			widget.NewFormItem("Restore on Login", restoreOnLoginCheck),
+			widget.NewFormItem("Configuration to Restore", defaultConfigSelect),
 			widget.NewFormItem("", widget.NewSeparator()),
 			widget.NewFormItem("Log Level", logLevelSelect),
 		},
@@ -374,18 +433,42 @@ func createPathsSettingsTab() fyne.CanvasObject {
 		}, FyneApp.Driver().AllWindows()[0])
 	})
 	
-	// Auto-detect button
-	autoDetectBtn := widget.NewButton("Auto-Detect MariaDB", func() {
-		dialog.ShowInformation("Auto-Detection", "Searching for MariaDB installation...", FyneApp.Driver().AllWindows()[0])
-		go func() {
-			core.DetectMariaDBBin()
-			fyne.Do(func() {
-				mariadbPathEntry.SetText(core.AppConfig.MariaDBBin)
-				dialog.ShowInformation("Auto-Detection Complete", 
-					fmt.Sprintf("MariaDB found at: %s", core.AppConfig.MariaDBBin), 
-					FyneApp.Driver().AllWindows()[0])
+	// Registered server installations configurations can be pinned to
+	installationsBox := container.NewVBox()
+	var refreshInstallations func()
+	refreshInstallations = func() {
+		installationsBox.Objects = nil
+		if len(core.AppConfig.Installations) == 0 {
+			installationsBox.Add(widget.NewLabel("None - all configurations use the directory above"))
+		}
+		for _, inst := range core.AppConfig.Installations {
+			name := inst.Name
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				core.RemoveInstallation(name)
+				refreshInstallations()
 			})
-		}()
+			installationsBox.Add(container.NewBorder(nil, nil, nil, removeBtn,
+				widget.NewLabel(fmt.Sprintf("%s  %s %s  %s", inst.Name, inst.Flavor, inst.Version, inst.BinDir))))
+		}
+		installationsBox.Refresh()
+	}
+	refreshInstallations()
+	
+	addInstallationBtn := widget.NewButtonWithIcon("Add Installation", theme.ContentAddIcon(), func() {
+		parent := FyneApp.Driver().AllWindows()[0]
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			registerInstallation(parent, uri.Path(), refreshInstallations)
+		}, parent)
+	})
+	
+	// Discover every installation and choose the binary directory or register some
+	discoverBtn := widget.NewButtonWithIcon("Discover Installations...", theme.SearchIcon(), func() {
+		showInstallationChooser(FyneApp.Driver().AllWindows()[0], func(binDir string) {
+			mariadbPathEntry.SetText(binDir)
+		}, refreshInstallations)
 	})
 	
 	pathsForm := &widget.Form{
@@ -396,14 +479,127 @@ func createPathsSettingsTab() fyne.CanvasObject {
 			widget.NewFormItem("Configuration Directory", 
 				container.NewBorder(nil, nil, nil, configBrowseBtn, configPathEntry)),
 			widget.NewFormItem("", configPathStatus),
+			widget.NewFormItem("Installations", installationsBox),
+			widget.NewFormItem("", addInstallationBtn),
 			widget.NewFormItem("", widget.NewSeparator()),
-			widget.NewFormItem("", autoDetectBtn),
+			widget.NewFormItem("", discoverBtn),
 		},
 	}
 	
 	return container.NewScroll(pathsForm)
 }
 
+// registerInstallation registers a bin directory in the background. The name
+// defaults to the version; when that can't be read, the user is asked for one.
+func registerInstallation(parent fyne.Window, binDir string, onRegistered func()) {
+	var register func(name string)
+	register = func(name string) {
+		go func() {
+			_, err := core.RegisterInstallation(name, binDir)
+			fyne.Do(func() {
+				switch {
+				case err == core.ErrInstallationNameRequired:
+					nameEntry := widget.NewEntry()
+					nameEntry.SetPlaceHolder("e.g. 10.11-custom")
+					items := []*widget.FormItem{
+						widget.NewFormItem("", widget.NewLabel(fmt.Sprintf("The server version in %s could not be read.", binDir))),
+						widget.NewFormItem("Name", nameEntry),
+					}
+					dialog.ShowForm("Name Installation", "Register", "Cancel", items, func(confirmed bool) {
+						if confirmed && strings.TrimSpace(nameEntry.Text) != "" {
+							register(strings.TrimSpace(nameEntry.Text))
+						}
+					}, parent)
+				case err != nil:
+					dialog.ShowError(err, parent)
+				default:
+					onRegistered()
+				}
+			})
+		}()
+	}
+	register("")
+}
+
+// showInstallationChooser lists every discovered server build. The selected one can
+// become the MariaDB binary directory or be registered as an installation.
+func showInstallationChooser(parent fyne.Window, onUse func(binDir string), onRegistered func()) {
+	var found []core.DiscoveredInstallation
+	selected := -1
+
+	statusLabel := widget.NewLabel("Searching for installations...")
+	list := widget.NewList(
+		func() int { return len(found) },
+		func() fyne.CanvasObject {
+			title := widget.NewLabel("Installation")
+			title.TextStyle = fyne.TextStyle{Bold: true}
+			return container.NewVBox(title, widget.NewLabel("Tools"))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			inst := found[i]
+			rows := o.(*fyne.Container).Objects
+			rows[0].(*widget.Label).SetText(inst.Label())
+			if inst.Image != "" {
+				rows[1].(*widget.Label).SetText(fmt.Sprintf("Use with runtime = container and image = %s", inst.Image))
+			} else {
+				rows[1].(*widget.Label).SetText("Tools: " + strings.Join(inst.Tools, ", "))
+			}
+		},
+	)
+
+	useBtn := widget.NewButton("Use as Binary Directory", func() {
+		if selected >= 0 {
+			onUse(found[selected].BinDir)
+		}
+	})
+	registerBtn := widget.NewButton("Register", func() {
+		if selected < 0 {
+			return
+		}
+		inst := found[selected]
+		registerInstallation(parent, inst.BinDir, func() {
+			onRegistered()
+			statusLabel.SetText(fmt.Sprintf("Registered %s %s", inst.Flavor, inst.Version))
+		})
+	})
+	useBtn.Disable()
+	registerBtn.Disable()
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		// Container images are used through a configuration's image= option
+		if found[id].Image != "" {
+			useBtn.Disable()
+			registerBtn.Disable()
+		} else {
+			useBtn.Enable()
+			registerBtn.Enable()
+		}
+	}
+
+	var d dialog.Dialog
+	closeBtn := widget.NewButton("Close", func() {
+		d.Hide()
+	})
+	content := container.NewBorder(statusLabel, container.NewHBox(useBtn, registerBtn, layout.NewSpacer(), closeBtn), nil, nil, list)
+	d = dialog.NewCustomWithoutButtons("Installations", content, parent)
+	d.Resize(fyne.NewSize(640, 420))
+	d.Show()
+
+	go func() {
+		discovered := core.DiscoverInstallations()
+		fyne.Do(func() {
+			found = discovered
+			if len(found) == 0 {
+				statusLabel.SetText("No MariaDB, MySQL or Percona installations found")
+			} else {
+				statusLabel.SetText(fmt.Sprintf("Found %d installation(s)", len(found)))
+			}
+			list.Refresh()
+		})
+	}()
+}
+
 // createAdvancedSettingsTabWithEntries creates the advanced settings tab and returns the numeric entries
 func createAdvancedSettingsTabWithEntries() (fyne.CanvasObject, *widget.Entry, *widget.Entry, *widget.Entry) {
 	// Process management settings
@@ -437,6 +633,76 @@ func createAdvancedSettingsTabWithEntries() (fyne.CanvasObject, *widget.Entry, *
 	})
 	backgroundProcessingCheck.SetChecked(core.AppConfig.BackgroundProcessing)
 	
+	// Metrics endpoint settings
+	metricsEndpointCheck := widget.NewCheck("Expose Prometheus metrics", func(checked bool) {
+		core.AppConfig.MetricsEndpointEnabled = checked
+	})
+	metricsEndpointCheck.SetChecked(core.AppConfig.MetricsEndpointEnabled)
+	
+	metricsAddrEntry := widget.NewEntry()
+	metricsAddrEntry.SetText(core.AppConfig.MetricsListenAddr)
+	metricsAddrEntry.SetPlaceHolder(core.DefaultMetricsListenAddr)
+	metricsAddrEntry.OnChanged = func(text string) {
+		core.AppConfig.MetricsListenAddr = strings.TrimSpace(text)
+	}
+	
+	// Proxy settings
+	proxyCheck := widget.NewCheck("Forward a fixed port to the active configuration", func(checked bool) {
+		core.AppConfig.ProxyEnabled = checked
+	})
+	proxyCheck.SetChecked(core.AppConfig.ProxyEnabled)
+	
+	proxyAddrEntry := widget.NewEntry()
+	proxyAddrEntry.SetText(core.AppConfig.ProxyListenAddr)
+	proxyAddrEntry.SetPlaceHolder(core.DefaultProxyListenAddr)
+	proxyAddrEntry.OnChanged = func(text string) {
+		core.AppConfig.ProxyListenAddr = strings.TrimSpace(text)
+	}
+	
+	proxyDrainEntry := widget.NewEntry()
+	proxyDrainEntry.SetText(strconv.Itoa(core.AppConfig.ProxyDrainSecs))
+	proxyDrainEntry.OnChanged = func(text string) {
+		if secs, err := strconv.Atoi(strings.TrimSpace(text)); err == nil && secs >= 0 {
+			core.AppConfig.ProxyDrainSecs = secs
+		}
+	}
+	
+	// Systemd service settings (Linux only)
+	useServicesCheck := widget.NewCheck("Start configurations as systemd services", func(checked bool) {
+		core.AppConfig.SystemdServices = checked
+	})
+	useServicesCheck.SetChecked(core.AppConfig.SystemdServices)
+	
+	serviceScopeSelect := widget.NewSelect([]string{core.ServiceScopeUser, core.ServiceScopeSystem}, func(value string) {
+		core.AppConfig.ServiceScope = value
+	})
+	serviceScopeSelect.SetSelected(core.AppConfig.ServiceScope)
+	
+	serviceModeSelect := widget.NewSelect([]string{core.ServiceModeUnit, core.ServiceModeDropIn}, func(value string) {
+		core.AppConfig.ServiceMode = value
+	})
+	serviceModeSelect.SetSelected(core.AppConfig.ServiceMode)
+	
+	stopDistroCheck := widget.NewCheck("Stop the distribution's MariaDB service before starting", func(checked bool) {
+		core.AppConfig.StopDistroService = checked
+	})
+	stopDistroCheck.SetChecked(core.AppConfig.StopDistroService)
+	
+	if !core.IsSystemdAvailable() {
+		useServicesCheck.Disable()
+		serviceScopeSelect.Disable()
+		serviceModeSelect.Disable()
+		stopDistroCheck.Disable()
+	}
+	
+	// Container runtime settings
+	containerCLIEntry := widget.NewEntry()
+	containerCLIEntry.SetText(core.AppConfig.ContainerCLI)
+	containerCLIEntry.SetPlaceHolder("docker or podman (auto-detected)")
+	containerCLIEntry.OnChanged = func(text string) {
+		core.AppConfig.ContainerCLI = strings.TrimSpace(text)
+	}
+	
 	advancedForm := &widget.Form{
 		Items: []*widget.FormItem{
 			widget.NewFormItem("Process Timeout (seconds)", processTimeoutEntry),
@@ -448,6 +714,20 @@ func createAdvancedSettingsTabWithEntries() (fyne.CanvasObject, *widget.Entry, *
 			widget.NewFormItem("Verbose Logging", verboseLoggingCheck),
 			widget.NewFormItem("", widget.NewSeparator()),
 			widget.NewFormItem("Background Processing", backgroundProcessingCheck),
+			widget.NewFormItem("", widget.NewSeparator()),
+			widget.NewFormItem("Metrics Endpoint", metricsEndpointCheck),
+			widget.NewFormItem("Metrics Listen Address", metricsAddrEntry),
+			widget.NewFormItem("", widget.NewSeparator()),
+			widget.NewFormItem("Proxy", proxyCheck),
+			widget.NewFormItem("Proxy Listen Address", proxyAddrEntry),
+			widget.NewFormItem("Proxy Drain (seconds)", proxyDrainEntry),
+			widget.NewFormItem("", widget.NewSeparator()),
+			widget.NewFormItem("Systemd Services", useServicesCheck),
+			widget.NewFormItem("Service Scope", serviceScopeSelect),
+			widget.NewFormItem("Service Mode", serviceModeSelect),
+			widget.NewFormItem("Distro Service", stopDistroCheck),
+			widget.NewFormItem("", widget.NewSeparator()),
+			widget.NewFormItem("Container CLI", containerCLIEntry),
 		},
 	}
 	
@@ -517,7 +797,7 @@ func createAboutSettingsTab() fyne.CanvasObject {
 func resetToDefaultSettings() {
 	// Reset core configuration to defaults
 	core.AppConfig.ConfigPath = core.GetUserConfigDir()
-	core.DetectMariaDBBin()
+	core.AppConfig.MariaDBBin = core.DetectMariaDBBin()
 	core.SaveConfig()
 	
 	dialog.ShowInformation("Settings Reset", "All settings have been reset to their default values.", MainWindow)
@@ -1,8 +1,11 @@
 package gui
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"mariadb-monitor/core"
@@ -93,4 +96,64 @@ func OpenFolder(path string) {
 	if cmd != nil {
 		cmd.Start()
 	}
-}
\ No newline at end of file
+}
+
+// linuxTerminals are the terminal emulators tried in order, with the argument
+// that precedes the command to run
+var linuxTerminals = [][2]string{
+	{"x-terminal-emulator", "-e"},
+	{"gnome-terminal", "--"},
+	{"konsole", "-e"},
+	{"xfce4-terminal", "-x"},
+	{"mate-terminal", "-x"},
+	{"tilix", "-e"},
+	{"kitty", ""},
+	{"alacritty", "-e"},
+	{"xterm", "-e"},
+}
+
+// OpenInTerminal runs a command in a new terminal window. On Linux $TERMINAL
+// is tried first, then common terminal emulators.
+func OpenInTerminal(args ...string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", append([]string{"/C", "start", ""}, args...)...)
+	case "darwin":
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = core.ShellQuote(arg)
+		}
+		script := strings.Join(quoted, " ")
+		script = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(script)
+		cmd = exec.Command("osascript",
+			"-e", `tell application "Terminal" to do script "`+script+`"`,
+			"-e", `tell application "Terminal" to activate`)
+	default:
+		terminals := linuxTerminals
+		if terminal := os.Getenv("TERMINAL"); terminal != "" {
+			terminals = append([][2]string{{terminal, "-e"}}, terminals...)
+		}
+		for _, terminal := range terminals {
+			if path, err := exec.LookPath(terminal[0]); err == nil {
+				terminalArgs := args
+				if terminal[1] != "" {
+					terminalArgs = append([]string{terminal[1]}, args...)
+				}
+				cmd = exec.Command(path, terminalArgs...)
+				break
+			}
+		}
+		if cmd == nil {
+			return fmt.Errorf("no terminal emulator found - set $TERMINAL")
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open a terminal: %v", err)
+	}
+	// Don't leave a zombie behind when the terminal exits
+	go cmd.Wait()
+	return nil
+}
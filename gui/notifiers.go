@@ -0,0 +1,316 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"mariadb-monitor/core"
+)
+
+// createNotificationChannelsTab creates the settings tab listing external notification channels.
+// Changes are saved immediately, like credentials, because SMTP passwords go to the keyring.
+func createNotificationChannelsTab(parent fyne.Window) fyne.CanvasObject {
+	selected := -1
+
+	channelList := widget.NewList(
+		func() int {
+			return len(core.AppConfig.NotificationChannels)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Channel")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < len(core.AppConfig.NotificationChannels) {
+				obj.(*widget.Label).SetText(formatChannelSummary(core.AppConfig.NotificationChannels[id]))
+			}
+		},
+	)
+	channelList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+	channelList.OnUnselected = func(id widget.ListItemID) {
+		selected = -1
+	}
+
+	addBtn := widget.NewButton("Add", func() {
+		showNotificationChannelDialog(parent, -1, func() {
+			channelList.Refresh()
+		})
+	})
+
+	editBtn := widget.NewButton("Edit", func() {
+		if selected < 0 || selected >= len(core.AppConfig.NotificationChannels) {
+			return
+		}
+		showNotificationChannelDialog(parent, selected, func() {
+			channelList.Refresh()
+		})
+	})
+
+	removeBtn := widget.NewButton("Remove", func() {
+		if selected < 0 || selected >= len(core.AppConfig.NotificationChannels) {
+			return
+		}
+		channel := core.AppConfig.NotificationChannels[selected]
+		dialog.ShowConfirm("Remove Channel",
+			fmt.Sprintf("Remove notification channel '%s'?", channel.Name),
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+				channels := core.AppConfig.NotificationChannels
+				core.AppConfig.NotificationChannels = append(channels[:selected:selected], channels[selected+1:]...)
+				core.DeleteChannelSecret(channel.Name)
+				if err := core.SaveConfig(); err != nil {
+					dialog.ShowError(fmt.Errorf("Failed to save settings: %v", err), parent)
+				}
+				channelList.UnselectAll()
+				channelList.Refresh()
+			}, parent)
+	})
+
+	testBtn := widget.NewButton("Send Test", func() {
+		if selected < 0 || selected >= len(core.AppConfig.NotificationChannels) {
+			return
+		}
+		testNotificationChannel(core.AppConfig.NotificationChannels[selected], parent)
+	})
+
+	help := widget.NewLabel("Send start, stop, crash and failed switch events to webhooks, Slack, email or a local command.")
+	help.Wrapping = fyne.TextWrapWord
+
+	return container.NewBorder(
+		help,
+		container.NewHBox(addBtn, editBtn, removeBtn, widget.NewSeparator(), testBtn),
+		nil, nil,
+		channelList,
+	)
+}
+
+// showNotificationChannelDialog shows the add/edit dialog for a channel (index -1 adds a new one)
+func showNotificationChannelDialog(parent fyne.Window, index int, onSaved func()) {
+	channel := core.NotificationChannel{Type: core.ChannelWebhook, Enabled: true, SMTPPort: 587}
+	if index >= 0 {
+		channel = core.AppConfig.NotificationChannels[index]
+	}
+	originalName := channel.Name
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(channel.Name)
+	nameEntry.SetPlaceHolder("ops-webhook")
+
+	enabledCheck := widget.NewCheck("Enabled", nil)
+	enabledCheck.SetChecked(channel.Enabled)
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(channel.URL)
+	urlEntry.SetPlaceHolder("https://example.com/hooks/dbswitcher")
+
+	commandEntry := widget.NewEntry()
+	commandEntry.SetText(channel.Command)
+	commandEntry.SetPlaceHolder("/usr/local/bin/on-db-event.sh")
+
+	smtpHostEntry := widget.NewEntry()
+	smtpHostEntry.SetText(channel.SMTPHost)
+	smtpHostEntry.SetPlaceHolder("smtp.example.com")
+
+	smtpPortEntry := widget.NewEntry()
+	if channel.SMTPPort > 0 {
+		smtpPortEntry.SetText(strconv.Itoa(channel.SMTPPort))
+	}
+	smtpPortEntry.SetPlaceHolder("587")
+
+	smtpUserEntry := widget.NewEntry()
+	smtpUserEntry.SetText(channel.SMTPUsername)
+
+	smtpPasswordEntry := widget.NewPasswordEntry()
+	smtpPasswordEntry.SetPlaceHolder("Stored in system keyring")
+	if password, err := core.LoadChannelSecret(channel.Name); err == nil && channel.Name != "" {
+		smtpPasswordEntry.SetText(password)
+	}
+
+	fromEntry := widget.NewEntry()
+	fromEntry.SetText(channel.From)
+	fromEntry.SetPlaceHolder("dbswitcher@example.com")
+
+	toEntry := widget.NewEntry()
+	toEntry.SetText(strings.Join(channel.To, ", "))
+	toEntry.SetPlaceHolder("ops@example.com, dba@example.com")
+
+	eventOptions := []string{}
+	for _, event := range core.AllNotificationEvents {
+		eventOptions = append(eventOptions, string(event))
+	}
+	eventsGroup := widget.NewCheckGroup(eventOptions, nil)
+	eventsGroup.Horizontal = true
+	if len(channel.Events) == 0 {
+		eventsGroup.SetSelected(eventOptions)
+	} else {
+		eventsGroup.SetSelected(channel.Events)
+	}
+
+	// Only show the fields relevant to the selected channel type
+	urlItem := widget.NewFormItem("URL", urlEntry)
+	commandItem := widget.NewFormItem("Command", commandEntry)
+	emailItems := []*widget.FormItem{
+		widget.NewFormItem("SMTP Host", smtpHostEntry),
+		widget.NewFormItem("SMTP Port", smtpPortEntry),
+		widget.NewFormItem("SMTP Username", smtpUserEntry),
+		widget.NewFormItem("SMTP Password", smtpPasswordEntry),
+		widget.NewFormItem("From", fromEntry),
+		widget.NewFormItem("To", toEntry),
+	}
+
+	form := widget.NewForm()
+	typeSelect := widget.NewSelect(
+		[]string{core.ChannelWebhook, core.ChannelSlack, core.ChannelEmail, core.ChannelCommand}, nil)
+
+	rebuildForm := func(channelType string) {
+		form.Items = []*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+			widget.NewFormItem("Type", typeSelect),
+			widget.NewFormItem("", enabledCheck),
+		}
+		switch channelType {
+		case core.ChannelWebhook, core.ChannelSlack:
+			form.Items = append(form.Items, urlItem)
+		case core.ChannelEmail:
+			form.Items = append(form.Items, emailItems...)
+		case core.ChannelCommand:
+			form.Items = append(form.Items, commandItem)
+		}
+		form.Items = append(form.Items, widget.NewFormItem("Events", eventsGroup))
+		form.Refresh()
+	}
+	typeSelect.OnChanged = rebuildForm
+	typeSelect.SetSelected(channel.Type)
+
+	// collect builds a channel from the form fields
+	collect := func() core.NotificationChannel {
+		port, _ := strconv.Atoi(strings.TrimSpace(smtpPortEntry.Text))
+		recipients := []string{}
+		for _, to := range strings.Split(toEntry.Text, ",") {
+			if to = strings.TrimSpace(to); to != "" {
+				recipients = append(recipients, to)
+			}
+		}
+		events := eventsGroup.Selected
+		if len(events) == len(eventOptions) {
+			events = nil // All events
+		}
+		return core.NotificationChannel{
+			Name:         strings.TrimSpace(nameEntry.Text),
+			Type:         typeSelect.Selected,
+			Enabled:      enabledCheck.Checked,
+			Events:       events,
+			URL:          strings.TrimSpace(urlEntry.Text),
+			Command:      strings.TrimSpace(commandEntry.Text),
+			SMTPHost:     strings.TrimSpace(smtpHostEntry.Text),
+			SMTPPort:     port,
+			SMTPUsername: strings.TrimSpace(smtpUserEntry.Text),
+			From:         strings.TrimSpace(fromEntry.Text),
+			To:           recipients,
+		}
+	}
+
+	// saveSecret stores the SMTP password under the channel's current name
+	saveSecret := func(name string) error {
+		if typeSelect.Selected != core.ChannelEmail || smtpPasswordEntry.Text == "" {
+			return core.DeleteChannelSecret(name)
+		}
+		return core.SaveChannelSecret(name, smtpPasswordEntry.Text)
+	}
+
+	testBtn := widget.NewButton("Send Test", func() {
+		candidate := collect()
+		candidate.SMTPPassword = smtpPasswordEntry.Text
+		testNotificationChannel(candidate, parent)
+	})
+
+	var d dialog.Dialog
+	saveBtn := widget.NewButton("Save", func() {
+		updated := collect()
+		if updated.Name == "" {
+			dialog.ShowError(fmt.Errorf("channel name is required"), parent)
+			return
+		}
+		for i, existing := range core.AppConfig.NotificationChannels {
+			if i != index && strings.EqualFold(existing.Name, updated.Name) {
+				dialog.ShowError(fmt.Errorf("a channel named '%s' already exists", updated.Name), parent)
+				return
+			}
+		}
+		if _, err := core.NewNotifier(updated); err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+
+		if originalName != "" && originalName != updated.Name {
+			core.DeleteChannelSecret(originalName)
+		}
+		if err := saveSecret(updated.Name); err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+
+		if index >= 0 {
+			core.AppConfig.NotificationChannels[index] = updated
+		} else {
+			core.AppConfig.NotificationChannels = append(core.AppConfig.NotificationChannels, updated)
+		}
+		if err := core.SaveConfig(); err != nil {
+			dialog.ShowError(fmt.Errorf("Failed to save settings: %v", err), parent)
+			return
+		}
+
+		d.Hide()
+		onSaved()
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	cancelBtn := widget.NewButton("Cancel", func() {
+		d.Hide()
+	})
+
+	title := "Add Notification Channel"
+	if index >= 0 {
+		title = "Edit Notification Channel"
+	}
+	content := container.NewBorder(nil, container.NewHBox(testBtn, widget.NewSeparator(), saveBtn, cancelBtn), nil, nil,
+		container.NewVScroll(form))
+	d = dialog.NewCustomWithoutButtons(title, content, parent)
+	d.Resize(fyne.NewSize(520, 480))
+	d.Show()
+}
+
+// testNotificationChannel sends a test message in the background and reports the result
+func testNotificationChannel(channel core.NotificationChannel, parent fyne.Window) {
+	go func() {
+		err := core.TestNotificationChannel(channel)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("Test notification failed: %v", err), parent)
+			} else {
+				dialog.ShowInformation("Test Sent", fmt.Sprintf("Test notification sent to '%s'.", channel.Name), parent)
+			}
+		})
+	}()
+}
+
+// formatChannelSummary returns the list label for a channel
+func formatChannelSummary(channel core.NotificationChannel) string {
+	state := "enabled"
+	if !channel.Enabled {
+		state = "disabled"
+	}
+	events := "all events"
+	if len(channel.Events) > 0 {
+		events = strings.Join(channel.Events, ", ")
+	}
+	return fmt.Sprintf("%s (%s, %s) - %s", channel.Name, channel.Type, state, events)
+}
@@ -30,7 +30,7 @@ func CreateQuickActionsCard() *widget.Card {
 						Content: fmt.Sprintf("Starting %s configuration...", config.Name),
 					})
 					
-					err := core.StartMariaDBWithConfig(config.Path)
+					err := core.SwitchToConfig(config, nil)
 					
 					// Update status after start attempt
 					RefreshMainUI()
@@ -133,6 +133,9 @@ func CreateQuickActionsCard() *widget.Card {
 					// Start with same config
 					if currentConfig != "" {
 						startErr := core.StartMariaDBWithConfig(currentConfig)
+						if startErr != nil {
+							core.NotifySwitchFailed(core.ConfigForPath(currentConfig).Name, startErr)
+						}
 						RefreshMainUI()
 						
 						// Update UI on main thread
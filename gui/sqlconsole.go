@@ -0,0 +1,326 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"mariadb-monitor/core"
+)
+
+// sqlConsolePageSize is the number of rows shown per page of a result set
+const sqlConsolePageSize = 100
+
+// sqlConsoleConfigSelect lists the configurations the console can query. It
+// is refreshed when the console tab is shown.
+var sqlConsoleConfigSelect *widget.Select
+
+// CreateSQLConsole creates the SQL console tab, which runs queries on the
+// running configuration with its credentials
+func CreateSQLConsole() fyne.CanvasObject {
+	var (
+		results []core.QueryResult
+		current int // Index of the result set shown
+		page    int
+		cancel  context.CancelFunc
+	)
+
+	databaseEntry := widget.NewEntry()
+	databaseEntry.SetPlaceHolder("Database (optional)")
+
+	sqlConsoleConfigSelect = widget.NewSelect(nil, func(name string) {
+		if cfg := core.FindConfigByName(name); cfg != nil {
+			databaseEntry.SetText(cfg.Options["init-database"])
+		}
+	})
+	sqlConsoleConfigSelect.PlaceHolder = "Configuration"
+	RefreshSQLConsole()
+
+	queryEntry := widget.NewMultiLineEntry()
+	queryEntry.SetPlaceHolder("SELECT ...")
+	queryEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	queryEntry.SetMinRowsVisible(5)
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	pageLabel := widget.NewLabel("")
+	resultSelect := widget.NewSelect(nil, nil)
+
+	table := widget.NewTable(
+		func() (int, int) {
+			if current >= len(results) {
+				return 0, 0
+			}
+			rows := len(results[current].Rows) - page*sqlConsolePageSize
+			if rows > sqlConsolePageSize {
+				rows = sqlConsolePageSize
+			}
+			return rows, len(results[current].Columns)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			label.SetText("")
+			if current >= len(results) {
+				return
+			}
+			rowIndex := page*sqlConsolePageSize + id.Row
+			if row := results[current].Rows; rowIndex < len(row) && id.Col < len(row[rowIndex]) {
+				if value := row[rowIndex][id.Col]; value != nil {
+					label.SetText(*value)
+				} else {
+					label.SetText("NULL")
+				}
+			}
+		},
+	)
+	table.ShowHeaderRow = true
+	table.CreateHeader = func() fyne.CanvasObject {
+		label := widget.NewLabel("")
+		label.TextStyle = fyne.TextStyle{Bold: true}
+		label.Truncation = fyne.TextTruncateEllipsis
+		return label
+	}
+	table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		label := o.(*widget.Label)
+		label.SetText("")
+		if current < len(results) && id.Col >= 0 && id.Col < len(results[current].Columns) {
+			label.SetText(results[current].Columns[id.Col])
+		}
+	}
+
+	var prevBtn, nextBtn *widget.Button
+	showResult := func() {
+		if current >= len(results) {
+			pageLabel.SetText("")
+			prevBtn.Disable()
+			nextBtn.Disable()
+			table.Refresh()
+			return
+		}
+		result := results[current]
+		for col := range result.Columns {
+			table.SetColumnWidth(col, 160)
+		}
+		first := page*sqlConsolePageSize + 1
+		last := first + sqlConsolePageSize - 1
+		if last > len(result.Rows) {
+			last = len(result.Rows)
+		}
+		text := fmt.Sprintf("Rows %d-%d of %d", first, last, len(result.Rows))
+		if len(result.Rows) == 0 {
+			text = "No rows"
+		}
+		if result.Truncated {
+			text += " (more rows were dropped)"
+		}
+		pageLabel.SetText(text)
+		if page > 0 {
+			prevBtn.Enable()
+		} else {
+			prevBtn.Disable()
+		}
+		if last < len(result.Rows) {
+			nextBtn.Enable()
+		} else {
+			nextBtn.Disable()
+		}
+		table.ScrollToTop()
+		table.Refresh()
+	}
+
+	prevBtn = widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		if page > 0 {
+			page--
+			showResult()
+		}
+	})
+	nextBtn = widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		page++
+		showResult()
+	})
+	prevBtn.Disable()
+	nextBtn.Disable()
+
+	resultSelect.OnChanged = func(choice string) {
+		for i, option := range resultSelect.Options {
+			if option == choice {
+				current, page = i, 0
+				showResult()
+				return
+			}
+		}
+	}
+
+	exportResult := func(extension string, write func(io.Writer, core.QueryResult) error) {
+		if current >= len(results) {
+			dialog.ShowInformation("Export", "Run a query with results first.", MainWindow)
+			return
+		}
+		result := results[current]
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, MainWindow)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := write(writer, result); err != nil {
+				dialog.ShowError(fmt.Errorf("failed to export results: %v", err), MainWindow)
+			}
+		}, MainWindow)
+		save.SetFileName("results." + extension)
+		save.Show()
+	}
+	csvBtn := widget.NewButtonWithIcon("CSV", theme.DocumentSaveIcon(), func() {
+		exportResult("csv", core.WriteQueryResultCSV)
+	})
+	jsonBtn := widget.NewButtonWithIcon("JSON", theme.DocumentSaveIcon(), func() {
+		exportResult("json", core.WriteQueryResultJSON)
+	})
+
+	var runBtn, cancelBtn, historyBtn *widget.Button
+	runBtn = widget.NewButtonWithIcon("Run", theme.MediaPlayIcon(), func() {
+		cfg := core.FindConfigByName(sqlConsoleConfigSelect.Selected)
+		if cfg == nil {
+			dialog.ShowInformation("SQL Console", "Select a configuration first.", MainWindow)
+			return
+		}
+		if !core.IsConfigActive(*cfg, core.CurrentStatus) && !core.IsOnDemandConfig(*cfg) {
+			dialog.ShowInformation("SQL Console", fmt.Sprintf("%s is not running. Start it first.", cfg.Name), MainWindow)
+			return
+		}
+		query := strings.TrimSpace(queryEntry.Text)
+		if query == "" {
+			return
+		}
+
+		ctx, stop := context.WithCancel(context.Background())
+		cancel = stop
+		runBtn.Disable()
+		cancelBtn.Enable()
+		statusLabel.SetText(fmt.Sprintf("Running on %s...", cfg.Name))
+
+		go func(cfg core.MariaDBConfig, database string) {
+			defer stop()
+			started := time.Now()
+			queryResults, err := core.RunQuery(ctx, cfg, database, query)
+			elapsed := time.Since(started)
+			if err := core.AddQueryHistory(cfg.Name, query); err != nil {
+				core.AppLogger.Warn("Failed to save query history: %v", err)
+			}
+
+			fyne.Do(func() {
+				runBtn.Enable()
+				cancelBtn.Disable()
+				results, current, page = queryResults, 0, 0
+
+				options := make([]string, len(results))
+				for i, result := range results {
+					options[i] = fmt.Sprintf("%d: %s", i+1, firstLine(result.Statement, 60))
+				}
+				resultSelect.Options = options
+				resultSelect.ClearSelected()
+				if len(options) > 0 {
+					resultSelect.SetSelectedIndex(0)
+				}
+				showResult()
+
+				switch {
+				case err != nil:
+					statusLabel.SetText("Error: " + err.Error())
+				case len(results) == 0:
+					statusLabel.SetText(fmt.Sprintf("Query OK (%.2fs)", elapsed.Seconds()))
+				default:
+					statusLabel.SetText(fmt.Sprintf("%d result set(s) in %.2fs", len(results), elapsed.Seconds()))
+				}
+			})
+		}(*cfg, strings.TrimSpace(databaseEntry.Text))
+	})
+	cancelBtn = widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), func() {
+		if cancel != nil {
+			cancel()
+		}
+	})
+	cancelBtn.Disable()
+
+	historyBtn = widget.NewButtonWithIcon("History", theme.HistoryIcon(), func() {
+		name := sqlConsoleConfigSelect.Selected
+		history := core.GetQueryHistory(name)
+		if len(history) == 0 {
+			dialog.ShowInformation("History", "No queries run on this configuration yet.", MainWindow)
+			return
+		}
+		items := make([]*fyne.MenuItem, len(history))
+		for i, query := range history {
+			items[i] = fyne.NewMenuItem(firstLine(query, 80), func() {
+				queryEntry.SetText(query)
+			})
+		}
+		canvas := fyne.CurrentApp().Driver().CanvasForObject(historyBtn)
+		position := fyne.CurrentApp().Driver().AbsolutePositionForObject(historyBtn).AddXY(0, historyBtn.Size().Height)
+		widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), canvas, position)
+	})
+
+	toolbar := container.NewBorder(nil, nil,
+		container.NewHBox(sqlConsoleConfigSelect, runBtn, cancelBtn, historyBtn),
+		nil,
+		databaseEntry,
+	)
+	resultBar := container.NewBorder(nil, nil,
+		container.NewHBox(prevBtn, nextBtn, pageLabel),
+		container.NewHBox(csvBtn, jsonBtn),
+		resultSelect,
+	)
+
+	split := container.NewVSplit(queryEntry, container.NewBorder(resultBar, nil, nil, nil, table))
+	split.Offset = 0.3
+
+	return container.NewBorder(
+		container.NewVBox(toolbar, widget.NewSeparator()),
+		statusLabel,
+		nil, nil,
+		split,
+	)
+}
+
+// RefreshSQLConsole updates the configurations offered by the SQL console and
+// selects the running one if none is selected
+func RefreshSQLConsole() {
+	if sqlConsoleConfigSelect == nil {
+		return
+	}
+	options := make([]string, len(core.AvailableConfigs))
+	for i, cfg := range core.AvailableConfigs {
+		options[i] = cfg.Name
+	}
+	sqlConsoleConfigSelect.Options = options
+	if sqlConsoleConfigSelect.Selected == "" && core.CurrentStatus.IsRunning && core.CurrentStatus.ConfigName != "" {
+		sqlConsoleConfigSelect.SetSelected(core.CurrentStatus.ConfigName)
+	}
+	sqlConsoleConfigSelect.Refresh()
+}
+
+// firstLine shortens a query to its first line and at most n characters for menus
+func firstLine(query string, n int) string {
+	line, _, multiline := strings.Cut(strings.TrimSpace(query), "\n")
+	if runes := []rune(line); len(runes) > n {
+		return string(runes[:n]) + "…"
+	} else if multiline {
+		return line + " …"
+	}
+	return line
+}
@@ -2,6 +2,7 @@ package gui
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -20,6 +21,8 @@ func CreateStatusCard() *widget.Card {
 	portLabel := widget.NewLabel("Port: -")
 	pidLabel := widget.NewLabel("PID: -")
 	dataLabel := widget.NewLabel("Data: -")
+	metricsLabel := widget.NewLabel("")
+	metricsLabel.TextStyle = fyne.TextStyle{Monospace: true}
 
 	card := widget.NewCard("MariaDB Status", "", container.NewVBox(
 		statusLabel,
@@ -31,6 +34,8 @@ func CreateStatusCard() *widget.Card {
 			pidLabel,
 		),
 		dataLabel,
+		widget.NewSeparator(),
+		metricsLabel,
 	))
 
 	// Store references for updates
@@ -41,6 +46,7 @@ func CreateStatusCard() *widget.Card {
 	infoContainer.Objects[2] = portLabel
 	infoContainer.Objects[3] = pidLabel
 	card.Content.(*fyne.Container).Objects[3] = dataLabel
+	card.Content.(*fyne.Container).Objects[5] = metricsLabel
 
 	return card
 }
@@ -57,6 +63,7 @@ func UpdateStatusCard(card *widget.Card) {
 		portLabel := infoContainer.Objects[2].(*widget.Label)
 		pidLabel := infoContainer.Objects[3].(*widget.Label)
 		dataLabel := content.Objects[3].(*widget.Label)
+		metricsLabel := content.Objects[5].(*widget.Label)
 
 		if core.CurrentStatus.IsRunning {
 			statusLabel.SetText("✅ MariaDB is Running")
@@ -65,6 +72,7 @@ func UpdateStatusCard(card *widget.Card) {
 			portLabel.SetText(fmt.Sprintf("Port: %s", core.CurrentStatus.Port))
 			pidLabel.SetText(fmt.Sprintf("PID: %d", core.CurrentStatus.ProcessID))
 			dataLabel.SetText(fmt.Sprintf("Data: %s", core.CurrentStatus.DataPath))
+			metricsLabel.SetText(formatStatusMetrics())
 		} else {
 			statusLabel.SetText("🔴 MariaDB is Stopped")
 			versionLabel.SetText("Version: -")
@@ -72,10 +80,23 @@ func UpdateStatusCard(card *widget.Card) {
 			portLabel.SetText("Port: -")
 			pidLabel.SetText("PID: -")
 			dataLabel.SetText("Data: -")
+			metricsLabel.SetText("")
 		}
 	})
 }
 
+// formatStatusMetrics renders the health metrics history for the status card
+func formatStatusMetrics() string {
+	lines := core.FormatHealthMetrics(core.GetMetricsHistory())
+	if len(lines) == 0 {
+		if err := core.LastMetricsError(); err != nil {
+			return "Health metrics unavailable - check saved credentials"
+		}
+		return "Collecting health metrics..."
+	}
+	return strings.Join(lines, "\n")
+}
+
 // FindStatusCard finds the status card in the UI (helper function)
 func FindStatusCard() *widget.Card {
 	if MainWindow == nil || MainWindow.Content() == nil {
@@ -111,11 +132,18 @@ func StartAutoRefresh() {
 					return
 				}
 				
+				// Poll status and collect health metrics
+				core.PollStatus()
+
 				// Update status card if it exists
 				if card := FindStatusCard(); card != nil {
 					UpdateStatusCard(card)
 					core.AppLogger.Debug("Auto-refreshed status (interval: %ds)", core.AppConfig.RefreshIntervalSecs)
 				}
+
+				// Disk usage is cached, so this only rescans when the cache is stale.
+				// The selection belongs to the UI thread, so read it there.
+				fyne.Do(func() { RefreshConfigDetails(false) })
 			case <-refreshDone:
 				core.AppLogger.Debug("Auto-refresh stopped")
 				return
@@ -92,7 +92,7 @@ func onTrayReady() {
 				// Stop MariaDB
 				core.AppLogger.Log("Stop MariaDB clicked from tray")
 				go func() {
-					creds := core.GetDefaultCredentials()
+					creds := core.GetRunningCredentials()
 					err := core.StopMySQLWithCredentials(creds)
 					if err != nil {
 						core.AppLogger.Log("Failed to stop MariaDB: %v", err)
@@ -152,7 +152,7 @@ func onTrayReady() {
 							cfg := core.AvailableConfigs[i]
 							core.AppLogger.Log("Starting MariaDB with config: %s", cfg.Name)
 							go func(config core.MariaDBConfig) {
-								err := core.StartMariaDBWithConfig(config.Path)
+								err := core.SwitchToConfig(config, nil)
 								if err != nil {
 									core.AppLogger.Log("Failed to start %s: %v", config.Name, err)
 								} else {
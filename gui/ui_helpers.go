@@ -1,7 +1,13 @@
 package gui
 
 import (
+	"fmt"
+	"strings"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
 	"mariadb-monitor/core"
 )
 
@@ -12,7 +18,7 @@ var SystrayRunning bool
 func StopMariaDBServiceWithUI(window fyne.Window, callback func(error)) {
 	go func() {
 		// Try to use saved credentials first
-		creds := core.GetDefaultCredentials()
+		creds := core.GetRunningCredentials()
 		err := core.StopMySQLWithCredentials(creds)
 		
 		// If credentials failed, show credential dialog
@@ -45,4 +51,43 @@ func GetMariaDBStatusWithUI(window fyne.Window, callback func(core.MariaDBStatus
 		status := core.GetMariaDBStatus()
 		callback(status)
 	}()
-}
\ No newline at end of file
+}
+
+// initProgressWindows holds the open initialization progress windows by config
+// name. It is only touched on the UI thread.
+var initProgressWindows = map[string]*widget.Entry{}
+
+// WatchInitProgress shows a window with the output of data directory
+// initialization whenever a configuration's data directory is set up
+func WatchInitProgress() {
+	core.OnInitProgress(func(configName, line string) {
+		fyne.Do(func() {
+			output, ok := initProgressWindows[configName]
+			if !ok {
+				output = showInitProgressWindow(configName)
+			}
+			output.Append(line + "\n")
+			output.CursorRow = strings.Count(output.Text, "\n")
+		})
+	})
+}
+
+// showInitProgressWindow opens the progress window for one configuration
+func showInitProgressWindow(configName string) *widget.Entry {
+	window := FyneApp.NewWindow(fmt.Sprintf("Initializing %s", configName))
+	window.Resize(fyne.NewSize(700, 400))
+
+	output := widget.NewMultiLineEntry()
+	output.Wrapping = fyne.TextWrapWord
+	output.TextStyle = fyne.TextStyle{Monospace: true}
+	output.Disable() // Read-only
+	initProgressWindows[configName] = output
+
+	window.SetOnClosed(func() {
+		delete(initProgressWindows, configName)
+	})
+	closeBtn := widget.NewButton("Close", window.Close)
+	window.SetContent(container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), closeBtn), nil, nil, output))
+	window.Show()
+	return output
+}
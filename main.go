@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"mariadb-monitor/cli"
 	"mariadb-monitor/core"
@@ -17,6 +18,15 @@ var (
 )
 
 func main() {
+	// The elevated helper runs as root and must not load the user's settings
+	if len(os.Args) > 1 && os.Args[1] == core.HelperCommand {
+		if err := core.RunElevatedHelper(os.Args[2:], os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize core subsystems
 	if err := initializeApplication(); err != nil {
 		fmt.Printf("Failed to initialize application: %v\n", err)
@@ -41,7 +51,7 @@ func main() {
 	// Check for --minimized flag
 	if command == "--minimized" {
 		core.AppLogger.Log("Starting application in GUI mode (minimized)")
-		if err := gui.RunWithOptions(true); err != nil {
+		if err := gui.RunAtLogin(); err != nil {
 			fmt.Printf("Error running GUI: %v\n", err)
 			os.Exit(1)
 		}
@@ -57,50 +67,216 @@ func main() {
 		if err := cli.List(); err != nil {
 			core.AppLogger.Log("List command failed: %v", err)
 			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 	case "status":
 		if err := cli.Status(); err != nil {
 			core.AppLogger.Log("Status command failed: %v", err)
 			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 	case "start":
 		if len(os.Args) < 3 {
 			fmt.Println("Error: Configuration name required")
 			fmt.Println("Usage: dbswitcher start <config-name>")
-			os.Exit(1)
+			exit(1)
 		}
 		configName := os.Args[2]
 		core.AppLogger.Log("Starting MariaDB with configuration: %s", configName)
 		if err := cli.Start(configName); err != nil {
 			core.AppLogger.Log("Start command failed: %v", err)
 			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 	case "switch":
 		if len(os.Args) < 3 {
 			fmt.Println("Error: Configuration name required")
 			fmt.Println("Usage: dbswitcher switch <config-name>")
-			os.Exit(1)
+			exit(1)
 		}
 		configName := os.Args[2]
 		core.AppLogger.Log("Switching to configuration: %s", configName)
-		if err := cli.Switch(configName); err != nil {
+		if err := cli.Switch(configName, os.Stdout); err != nil {
 			core.AppLogger.Log("Switch command failed: %v", err)
 			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 	case "stop":
 		core.AppLogger.Log("Stopping MariaDB")
-		if err := cli.Stop(); err != nil {
+		if err := cli.Stop(os.Stdout); err != nil {
 			core.AppLogger.Log("Stop command failed: %v", err)
 			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+			exit(1)
+		}
+
+	case "du":
+		if len(os.Args) < 3 {
+			fmt.Println("Error: Configuration name required")
+			fmt.Println("Usage: dbswitcher du <config-name>")
+			exit(1)
+		}
+		configName := os.Args[2]
+		if err := cli.DiskUsage(configName); err != nil {
+			core.AppLogger.Log("Disk usage command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "top":
+		if err := cli.Top(); err != nil {
+			core.AppLogger.Log("Top command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "fix-perms":
+		if len(os.Args) < 3 {
+			fmt.Println("Error: Configuration name required")
+			fmt.Println("Usage: dbswitcher fix-perms <config-name>")
+			exit(1)
+		}
+		if err := cli.FixPerms(os.Args[2]); err != nil {
+			core.AppLogger.Log("Fix permissions command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "upgrade":
+		if len(os.Args) < 3 {
+			fmt.Println("Error: Configuration name required")
+			fmt.Println("Usage: dbswitcher upgrade <config-name>")
+			exit(1)
+		}
+		if err := cli.Upgrade(os.Args[2]); err != nil {
+			core.AppLogger.Log("Upgrade command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "env":
+		configName, format, includePassword := "", string(core.FormatShell), false
+		for i := 2; i < len(os.Args); i++ {
+			switch arg := os.Args[i]; {
+			case arg == "--show-password":
+				includePassword = true
+			case arg == "--format" && i+1 < len(os.Args):
+				i++
+				format = os.Args[i]
+			case strings.HasPrefix(arg, "--format="):
+				format = strings.TrimPrefix(arg, "--format=")
+			default:
+				configName = arg
+			}
+		}
+		if err := cli.Env(configName, format, includePassword); err != nil {
+			core.AppLogger.Log("Env command failed: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+	case "shell":
+		configName := ""
+		if len(os.Args) > 2 {
+			configName = os.Args[2]
+		}
+		if err := cli.Shell(configName); err != nil {
+			core.AppLogger.Log("Shell command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "use":
+		export := len(os.Args) > 2 && os.Args[2] == "--export"
+		if err := cli.Use(export); err != nil {
+			core.AppLogger.Log("Use command failed: %v", err)
+			// With --export the shell evaluates stdout
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+	case "hook":
+		if len(os.Args) < 3 {
+			fmt.Println("Error: Shell required")
+			fmt.Println("Usage: dbswitcher hook <bash|zsh>")
+			exit(1)
+		}
+		if err := cli.Hook(os.Args[2]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "on-demand":
+		if err := cli.OnDemand(); err != nil {
+			core.AppLogger.Log("On-demand command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "proxy":
+		if err := cli.Proxy(); err != nil {
+			core.AppLogger.Log("Proxy command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "run":
+		ephemeral, templateName := false, ""
+		for i := 2; i < len(os.Args); i++ {
+			switch os.Args[i] {
+			case "--ephemeral":
+				ephemeral = true
+			case "--template":
+				if i+1 < len(os.Args) {
+					i++
+					templateName = os.Args[i]
+				}
+			}
+		}
+		if !ephemeral {
+			fmt.Println("Error: only ephemeral instances can be run")
+			fmt.Println("Usage: dbswitcher run --ephemeral [--template <config-name>]")
+			exit(1)
+		}
+		if err := cli.RunEphemeral(templateName); err != nil {
+			core.AppLogger.Log("Run command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "reset":
+		if len(os.Args) < 3 {
+			fmt.Println("Error: Configuration name required")
+			fmt.Println("Usage: dbswitcher reset <config-name> [--delete]")
+			exit(1)
+		}
+		deleteData := len(os.Args) > 3 && os.Args[3] == "--delete"
+		if err := cli.Reset(os.Args[2], deleteData); err != nil {
+			core.AppLogger.Log("Reset command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "service":
+		if len(os.Args) < 4 {
+			fmt.Println("Error: Action and configuration name required")
+			fmt.Println("Usage: dbswitcher service <install|uninstall|enable|disable|status> <config-name>")
+			exit(1)
+		}
+		if err := cli.Service(os.Args[2], os.Args[3]); err != nil {
+			core.AppLogger.Log("Service command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
+	case "installations":
+		if err := cli.Installations(os.Args[2:]); err != nil {
+			core.AppLogger.Log("Installations command failed: %v", err)
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
 		}
 
 	case "gui":
@@ -108,7 +284,7 @@ func main() {
 		if err := gui.Run(); err != nil {
 			core.AppLogger.Log("GUI mode failed: %v", err)
 			fmt.Printf("Error running GUI: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 	case "tray":
@@ -116,7 +292,7 @@ func main() {
 		if err := gui.RunTray(); err != nil {
 			core.AppLogger.Log("Tray mode failed: %v", err)
 			fmt.Printf("Error running tray: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 	case "help", "--help", "-h":
@@ -131,8 +307,17 @@ func main() {
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		fmt.Println("Use 'help' for usage information")
-		os.Exit(1)
+		exit(1)
 	}
+
+	// Deliver notifications the command triggered before exiting
+	core.WaitForNotifications()
+}
+
+// exit delivers pending notifications and exits with a status code
+func exit(code int) {
+	core.WaitForNotifications()
+	os.Exit(code)
 }
 
 // initializeApplication initializes all core subsystems